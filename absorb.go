@@ -0,0 +1,40 @@
+package bpool
+
+// AbsorbFrom moves the idle buffers of src into bp without blocking, so that
+// warm buffers survive consolidating two pools into one. Buffers are subject
+// to the size and the capacity limit of bp like on Put; those bp doesn't
+// retain are released, and src is left empty. Buffers handed out by src must
+// still be returned to src. Buffers src allocated through an Allocator are
+// released unless bp uses an Allocator too, which must be the same, since bp
+// frees the buffers it absorbs.
+func (bp *SizedBufferPool) AbsorbFrom(src *SizedBufferPool) {
+	if bp == src {
+		return
+	}
+	for {
+		b, ok := src.take()
+		if !ok {
+			return
+		}
+		src.forget(b)
+		if bp.arrays == nil && src.arrays != nil {
+			// bp couldn't hand the buffer back to the allocator.
+			src.free(b)
+			continue
+		}
+		bp.moveArray(src, b, b)
+		if bp.isClosed() || (bp.maxCap() > 0 && b.Cap() > bp.maxCap()) ||
+			(bp.opt.absoluteMaxCap > 0 && b.Cap() > bp.opt.absoluteMaxCap) {
+			bp.free(b)
+			continue
+		}
+		bp.reset(b)
+		if bp.idle != nil {
+			bp.markIdle(b)
+		}
+		if !bp.give(b) {
+			bp.forget(b)
+			bp.free(b)
+		}
+	}
+}
@@ -0,0 +1,56 @@
+package bpool
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestSizedBufferPoolAbsorbFrom(t *testing.T) {
+	src := NewSizedBufferPool(6, 64)
+	for i := 0; i < 6; i++ {
+		src.Put(bytes.NewBuffer(make([]byte, 0, 64)))
+	}
+	dst := NewSizedBufferPool(4, 64)
+	dst.Put(bytes.NewBuffer(make([]byte, 0, 64)))
+
+	dst.AbsorbFrom(src)
+	if n := dst.Len(); n != 4 {
+		t.Fatalf("dst len invalid: got %v want %v", n, 4)
+	}
+	if n := src.Len(); n != 0 {
+		t.Fatalf("src len invalid: got %v want %v", n, 0)
+	}
+
+	// Oversized buffers are left out.
+	src.Put(bytes.NewBuffer(make([]byte, 0, 1024)))
+	dst = NewSizedBufferPoolWithMax(4, 64, 128)
+	dst.AbsorbFrom(src)
+	if n := dst.Len(); n != 0 {
+		t.Fatalf("dst len after absorbing oversized buffer invalid: got %v want %v", n, 0)
+	}
+}
+
+func TestSizedBufferPoolAbsorbFromAllocator(t *testing.T) {
+	alloc := &countingAllocator{live: make(map[*byte]bool)}
+	src := NewSizedBufferPoolWithOptions(WithSize(2), WithAlloc(64),
+		WithAllocator(alloc))
+	src.PutN(src.GetN(2))
+	dst := NewSizedBufferPoolWithOptions(WithSize(4), WithAlloc(64),
+		WithAllocator(alloc))
+
+	dst.AbsorbFrom(src)
+	if n := dst.Len(); n != 2 {
+		t.Fatalf("dst len invalid: got %v want %v", n, 2)
+	}
+	dst.Drain()
+	if n := alloc.len(); n != 0 {
+		t.Fatalf("live buffers after Drain invalid: got %v want %v", n, 0)
+	}
+
+	// A pool without an Allocator can't free the buffers, so src does.
+	src.PutN(src.GetN(2))
+	NewSizedBufferPool(4, 64).AbsorbFrom(src)
+	if n := alloc.len(); n != 0 {
+		t.Fatalf("live buffers after absorbing without Allocator invalid: got %v want %v", n, 0)
+	}
+}
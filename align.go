@@ -0,0 +1,15 @@
+package bpool
+
+import (
+	"unsafe"
+)
+
+// alignedBytes returns an empty slice with the given capacity whose backing
+// array starts at an address that is a multiple of align. It over-allocates
+// by up to align-1 bytes to find such an address.
+func alignedBytes(capacity, align int) []byte {
+	buf := make([]byte, addCap(capacity, align-1))
+	addr := uintptr(unsafe.Pointer(unsafe.SliceData(buf)))
+	off := int((uintptr(align) - addr%uintptr(align)) % uintptr(align))
+	return buf[off : off : off+capacity]
+}
@@ -0,0 +1,23 @@
+package bpool
+
+import (
+	"testing"
+	"unsafe"
+)
+
+func TestSizedBufferPoolAlignment(t *testing.T) {
+	for _, align := range []int{64, 4096} {
+		bufPool := NewSizedBufferPoolWithOptions(WithSize(4), WithAlloc(100),
+			WithAlignment(align))
+		for i := 0; i < 4; i++ {
+			b := bufPool.Get()
+			if b.Cap() != 100 {
+				t.Fatalf("aligned buffer capacity invalid: got %v want %v", b.Cap(), 100)
+			}
+			addr := uintptr(unsafe.Pointer(&b.Bytes()[:1][0]))
+			if addr%uintptr(align) != 0 {
+				t.Fatalf("buffer not aligned to %d: got address %#x", align, addr)
+			}
+		}
+	}
+}
@@ -0,0 +1,99 @@
+package bpool
+
+import (
+	"bytes"
+	"runtime"
+	"weak"
+)
+
+// Allocator provides the backing arrays of a pool's buffers. Alloc returns a
+// slice with a capacity of at least capacity bytes; its length is ignored.
+// Free is called with the array Alloc returned for every buffer the pool
+// discards, resliced to its full capacity, even if the buffer has since
+// outgrown it and been reallocated by bytes.Buffer. Buffers the pool didn't
+// allocate, such as those given to Put from elsewhere, aren't passed to Free,
+// and neither are those of buffers that are never returned to the pool.
+// An Allocator must be safe for concurrent use.
+type Allocator interface {
+	Alloc(capacity int) []byte
+	Free(b []byte)
+}
+
+// HeapAllocator is the default Allocator. It allocates from the Go heap and
+// leaves freeing to the garbage collector.
+type HeapAllocator struct{}
+
+// Alloc allocates a new slice with the given capacity.
+func (HeapAllocator) Alloc(capacity int) []byte {
+	return make([]byte, 0, capacity)
+}
+
+// Free does nothing.
+func (HeapAllocator) Free([]byte) {}
+
+// WithAllocator makes the pool allocate and free the backing arrays of its
+// buffers through a. WithAllocFunc takes precedence over it, and it has no
+// effect on alignment, which is left to a.
+func WithAllocator(a Allocator) Option {
+	return func(o *options) {
+		o.allocator = a
+	}
+}
+
+// evict discards b, which has been taken out of the pool for good, and hands
+// its memory back to the allocator.
+func (bp *SizedBufferPool) evict(b *bytes.Buffer) {
+	bp.forget(b)
+	bp.free(b)
+}
+
+// track records that a is the array allocated for b. The record holds b
+// weakly, so that a buffer leaked by its caller can still be garbage
+// collected, and is dropped once it is. The array of a leaked buffer is never
+// freed, since slices of it may outlive the buffer.
+func (bp *SizedBufferPool) track(b *bytes.Buffer, a []byte) {
+	wb := weak.Make(b)
+	bp.arraysMu.Lock()
+	bp.arrays[wb] = a
+	bp.arraysMu.Unlock()
+	runtime.AddCleanup(b, bp.untrack, wb)
+}
+
+// untrack drops the record of a buffer that has been garbage collected.
+func (bp *SizedBufferPool) untrack(wb weak.Pointer[bytes.Buffer]) {
+	bp.arraysMu.Lock()
+	delete(bp.arrays, wb)
+	bp.arraysMu.Unlock()
+}
+
+// untracked removes and returns the array allocated for b, if b was
+// allocated by an allocator.
+func (bp *SizedBufferPool) untracked(b *bytes.Buffer) ([]byte, bool) {
+	if bp.arrays == nil {
+		return nil, false
+	}
+	wb := weak.Make(b)
+	bp.arraysMu.Lock()
+	a, ok := bp.arrays[wb]
+	delete(bp.arrays, wb)
+	bp.arraysMu.Unlock()
+	return a, ok
+}
+
+// free hands the array allocated for b back to the allocator, if b was
+// allocated by one. b must not be used afterwards.
+func (bp *SizedBufferPool) free(b *bytes.Buffer) {
+	if a, ok := bp.untracked(b); ok {
+		b.Reset()
+		bp.opt.allocator.Free(a[:cap(a)])
+	}
+}
+
+// moveArray transfers the array allocated for from by src to to, which has
+// taken over from's contents, in bp. from and to may be the same buffer, and
+// src and bp the same pool. bp must have an allocator if src does.
+func (bp *SizedBufferPool) moveArray(src *SizedBufferPool, from, to *bytes.Buffer) {
+	if a, ok := src.untracked(from); ok {
+		bp.track(to, a)
+	}
+}
@@ -0,0 +1,64 @@
+package bpool
+
+import (
+	"sync"
+	"testing"
+	"unsafe"
+)
+
+// countingAllocator tracks the arrays it allocated that have not been freed.
+type countingAllocator struct {
+	mu   sync.Mutex
+	live map[*byte]bool
+}
+
+func (a *countingAllocator) Alloc(capacity int) []byte {
+	b := make([]byte, 0, capacity)
+	a.mu.Lock()
+	a.live[unsafe.SliceData(b)] = true
+	a.mu.Unlock()
+	return b
+}
+
+func (a *countingAllocator) Free(b []byte) {
+	a.mu.Lock()
+	delete(a.live, unsafe.SliceData(b))
+	a.mu.Unlock()
+}
+
+func (a *countingAllocator) len() int {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return len(a.live)
+}
+
+func TestSizedBufferPoolAllocator(t *testing.T) {
+	alloc := &countingAllocator{live: make(map[*byte]bool)}
+	bufPool := NewSizedBufferPoolWithOptions(WithSize(2), WithAlloc(16),
+		WithAllocator(alloc))
+
+	bufs := bufPool.GetN(4)
+	if alloc.len() != 4 {
+		t.Fatalf("allocated buffers invalid: got %v want %v", alloc.len(), 4)
+	}
+	bufPool.PutN(bufs)
+	// The pool retains two buffers and frees the others.
+	if alloc.len() != 2 {
+		t.Fatalf("live buffers after Put invalid: got %v want %v", alloc.len(), 2)
+	}
+
+	bufPool.Drain()
+	if alloc.len() != 0 {
+		t.Fatalf("live buffers after Drain invalid: got %v want %v", alloc.len(), 0)
+	}
+
+	// Buffers that outgrew their allocation still free the original array.
+	b := bufPool.Get()
+	b.Write(make([]byte, 100))
+	bufPool.Drain()
+	bufPool.Put(b)
+	bufPool.Drain()
+	if alloc.len() != 0 {
+		t.Fatalf("live buffers after growth invalid: got %v want %v", alloc.len(), 0)
+	}
+}
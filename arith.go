@@ -0,0 +1,24 @@
+package bpool
+
+import (
+	"math"
+)
+
+// addCap returns a+b for non-negative capacities, saturating at math.MaxInt
+// instead of overflowing into a small or negative capacity.
+func addCap(a, b int) int {
+	if a > math.MaxInt-b {
+		return math.MaxInt
+	}
+	return a + b
+}
+
+// scaleCap returns capacity multiplied by a non-negative factor, saturating
+// at math.MaxInt instead of overflowing.
+func scaleCap(capacity int, factor float64) int {
+	v := float64(capacity) * factor
+	if v >= math.MaxInt {
+		return math.MaxInt
+	}
+	return int(v)
+}
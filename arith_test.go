@@ -0,0 +1,32 @@
+package bpool
+
+import (
+	"math"
+	"testing"
+)
+
+func TestCapArithmetic(t *testing.T) {
+	for _, tc := range []struct {
+		name      string
+		got, want int
+	}{
+		{"addCap", addCap(math.MaxInt/2, math.MaxInt/2), math.MaxInt - 1},
+		{"addCap saturated", addCap(math.MaxInt, 1), math.MaxInt},
+		{"addCap near max", addCap(math.MaxInt-1, math.MaxInt32), math.MaxInt},
+		{"scaleCap", scaleCap(math.MaxInt32, 1), math.MaxInt32},
+		{"scaleCap saturated", scaleCap(math.MaxInt/2, 4), math.MaxInt},
+		{"roundSizeClass near max", roundSizeClass(math.MaxInt - 1), math.MaxInt - 1},
+	} {
+		if tc.got != tc.want {
+			t.Fatalf("%s invalid: got %v want %v", tc.name, tc.got, tc.want)
+		}
+	}
+}
+
+func TestSizedBufferPoolRetainToleranceOverflow(t *testing.T) {
+	bufPool := NewSizedBufferPoolWithOptions(WithSize(1), WithRetainTolerance(4))
+	bufPool.SetCap(math.MaxInt / 2)
+	if got := bufPool.maxCap(); got != math.MaxInt {
+		t.Fatalf("retain limit overflowed: got %v want %v", got, math.MaxInt)
+	}
+}
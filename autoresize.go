@@ -0,0 +1,66 @@
+package bpool
+
+import (
+	"math"
+	"sync"
+)
+
+// autoResizeWindow is the number of Gets after which a pool created with
+// WithAutoResize reconsiders its size.
+const autoResizeWindow = 1000
+
+// autoResize tracks the demand on a pool over the current window of Gets.
+type autoResize struct {
+	mu     sync.Mutex
+	gets   int
+	misses int
+	// low is the lowest number of idle buffers seen in the window. Buffers
+	// that stayed idle for the whole window weren't needed.
+	low int
+}
+
+// observeGet records a Get and resizes the pool at the end of each window:
+// it doubles in size when more than a tenth of the Gets missed, and sheds its
+// unneeded buffers when none did, within the bounds set by WithAutoResize.
+func (bp *SizedBufferPool) observeGet() {
+	a := bp.auto
+	a.mu.Lock()
+	a.gets++
+	if a.gets < autoResizeWindow {
+		a.mu.Unlock()
+		return
+	}
+	size := bp.idleCap()
+	next := size
+	switch {
+	case a.misses*10 > a.gets:
+		next = max(addCap(size, size), 1)
+	case a.misses == 0:
+		next = size - a.low
+	}
+	next = min(max(next, bp.opt.autoMin), bp.opt.autoMax)
+	a.gets, a.misses, a.low = 0, 0, math.MaxInt
+	a.mu.Unlock()
+
+	if next != size {
+		bp.Resize(next)
+	}
+}
+
+// observeIdle records the number of idle buffers left after a Get reused
+// one.
+func (bp *SizedBufferPool) observeIdle() {
+	n := bp.idleLen()
+	bp.auto.mu.Lock()
+	bp.auto.low = min(bp.auto.low, n)
+	bp.auto.mu.Unlock()
+}
+
+// observeMiss records a Get that had to allocate, having found no idle
+// buffer.
+func (bp *SizedBufferPool) observeMiss() {
+	bp.auto.mu.Lock()
+	bp.auto.misses++
+	bp.auto.low = 0
+	bp.auto.mu.Unlock()
+}
@@ -0,0 +1,41 @@
+package bpool
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestSizedBufferPoolAutoResize(t *testing.T) {
+	bufPool := NewSizedBufferPoolWithOptions(WithSize(4), WithAlloc(64),
+		WithAutoResize(2, 64))
+
+	// High demand: 32 buffers are in use at once, so a pool of 4 keeps
+	// missing and grows until it holds them all.
+	bufs := make([]*bytes.Buffer, 32)
+	for round := 0; round < 200; round++ {
+		for i := range bufs {
+			bufs[i] = bufPool.Get()
+		}
+		bufPool.PutN(bufs)
+	}
+	if got := bufPool.idleCap(); got < 32 || got > 64 {
+		t.Fatalf("pool didn't grow with demand: got size %v want 32 to 64", got)
+	}
+
+	// Low demand: a single buffer is in use, so the pool shrinks to its
+	// minimum.
+	for i := 0; i < 5*autoResizeWindow; i++ {
+		bufPool.Put(bufPool.Get())
+	}
+	if got := bufPool.idleCap(); got != 2 {
+		t.Fatalf("pool didn't shrink with demand: got size %v want %v", got, 2)
+	}
+}
+
+func TestSizedBufferPoolAutoResizeBounds(t *testing.T) {
+	bufPool := NewSizedBufferPoolWithOptions(WithSize(100), WithAlloc(64),
+		WithAutoResize(2, 8))
+	if got := bufPool.idleCap(); got != 8 {
+		t.Fatalf("initial size not clamped: got %v want %v", got, 8)
+	}
+}
@@ -0,0 +1,58 @@
+package bpool
+
+import (
+	"bytes"
+)
+
+// GetN gets n Buffers from the SizedBufferPool at once. It takes whatever
+// idle buffers are available and allocates the rest, without retrying the
+// pool once it has been found empty. With WithMaxLive, at most that many
+// buffers are returned, since a batch waiting on its own buffers would block
+// forever.
+func (bp *SizedBufferPool) GetN(n int) []*bytes.Buffer {
+	bufs := make([]*bytes.Buffer, n)
+	return bufs[:bp.GetAllInto(bufs)]
+}
+
+// GetAllInto is like GetN, but stores the buffers in dst, filling its whole
+// length, so that batches can reuse the same slice. It returns the number of
+// buffers stored: with WithMaxLive, only the first maxLive slots are filled
+// and the rest are set to nil.
+func (bp *SizedBufferPool) GetAllInto(dst []*bytes.Buffer) (n int) {
+	if bp.opt.maxLive > 0 {
+		n = min(len(dst), bp.opt.maxLive)
+		for i := range dst[:n] {
+			dst[i] = bp.Get()
+		}
+		clear(dst[n:])
+		return
+	}
+	empty := false
+	for i := range dst {
+		bp.count(&bp.s.gets)
+		bp.acquire()
+		if !empty {
+			if b, ok := bp.take(); ok {
+				dst[i] = bp.reuse(b)
+				continue
+			}
+			empty = true
+		}
+		dst[i] = bp.allocLimited(bp.get, nil)
+	}
+	return len(dst)
+}
+
+// PutN returns all the given Buffers to the SizedBufferPool.
+func (bp *SizedBufferPool) PutN(bufs []*bytes.Buffer) {
+	for _, b := range bufs {
+		bp.Put(b)
+	}
+}
+
+// PutAll is like PutN, but also clears bufs, so that a slice kept for reuse
+// with GetAllInto doesn't hold on to returned buffers.
+func (bp *SizedBufferPool) PutAll(bufs []*bytes.Buffer) {
+	bp.PutN(bufs)
+	clear(bufs)
+}
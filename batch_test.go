@@ -0,0 +1,88 @@
+package bpool
+
+import (
+	"bytes"
+	"testing"
+	"time"
+)
+
+func TestSizedBufferPoolGetN(t *testing.T) {
+	var size int = 4
+
+	bufPool := NewSizedBufferPool(size, 64)
+	bufPool.Prefill()
+
+	// Ask for more buffers than the pool holds.
+	bufs := bufPool.GetN(size * 2)
+	if len(bufs) != size*2 {
+		t.Fatalf("GetN returned wrong count: got %v want %v", len(bufs), size*2)
+	}
+	seen := make(map[any]bool)
+	for _, b := range bufs {
+		if b == nil || seen[b] {
+			t.Fatalf("GetN returned a nil or duplicate buffer")
+		}
+		seen[b] = true
+	}
+	if got := bufPool.Stats(); got.Gets != uint64(size*2) || got.Misses != uint64(size) {
+		t.Fatalf("GetN stats invalid: got %+v", got)
+	}
+
+	bufPool.PutN(bufs)
+	if bufPool.Len() != size {
+		t.Fatalf("PutN didn't refill the pool: got %v want %v", bufPool.Len(), size)
+	}
+}
+
+func TestSizedBufferPoolGetAllInto(t *testing.T) {
+	bufPool := NewSizedBufferPool(100, 64)
+
+	batch := make([]*bytes.Buffer, 100)
+	for round := 0; round < 2; round++ {
+		bufPool.GetAllInto(batch)
+		for i, b := range batch {
+			if b == nil || b.Len() != 0 {
+				t.Fatalf("round %d: buffer %d not empty", round, i)
+			}
+			b.WriteString("hello")
+		}
+		bufPool.PutAll(batch)
+		for i, b := range batch {
+			if b != nil {
+				t.Fatalf("round %d: slot %d not cleared", round, i)
+			}
+		}
+		if bufPool.Len() != 100 {
+			t.Fatalf("round %d: PutAll didn't refill the pool: got %v want %v",
+				round, bufPool.Len(), 100)
+		}
+	}
+	if misses := bufPool.Stats().Misses; misses != 100 {
+		t.Fatalf("batch not reused: got %v misses want %v", misses, 100)
+	}
+}
+
+func TestSizedBufferPoolGetNMaxLive(t *testing.T) {
+	bufPool := NewSizedBufferPoolWithOptions(WithSize(4), WithAlloc(64),
+		WithMaxLive(2))
+
+	done := make(chan []*bytes.Buffer)
+	go func() {
+		done <- bufPool.GetN(3)
+	}()
+	select {
+	case bufs := <-done:
+		if len(bufs) != 2 {
+			t.Fatalf("GetN beyond maxLive invalid: got %v buffers want %v", len(bufs), 2)
+		}
+		bufPool.PutN(bufs)
+	case <-time.After(time.Second):
+		t.Fatalf("GetN beyond maxLive blocked")
+	}
+
+	batch := make([]*bytes.Buffer, 3)
+	batch[2] = new(bytes.Buffer)
+	if n := bufPool.GetAllInto(batch); n != 2 || batch[2] != nil {
+		t.Fatalf("GetAllInto beyond maxLive invalid: got %v, %v want %v, nil", n, batch[2], 2)
+	}
+}
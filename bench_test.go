@@ -0,0 +1,79 @@
+package bpool
+
+import (
+	"bytes"
+	"fmt"
+	"sync"
+	"testing"
+)
+
+var benchSizes = []int{256, 16 << 10, 1 << 20}
+
+// BenchmarkPools compares SizedBufferPool with plain sync.Pool and with no
+// pooling at all, for small, medium and large buffers under concurrent use.
+func BenchmarkPools(b *testing.B) {
+	for _, size := range benchSizes {
+		data := make([]byte, size)
+
+		b.Run(fmt.Sprintf("NoPool/%d", size), func(b *testing.B) {
+			b.ReportAllocs()
+			b.RunParallel(func(pb *testing.PB) {
+				for pb.Next() {
+					buf := bytes.NewBuffer(make([]byte, 0, size))
+					buf.Write(data)
+				}
+			})
+		})
+
+		b.Run(fmt.Sprintf("SyncPool/%d", size), func(b *testing.B) {
+			pool := sync.Pool{
+				New: func() any {
+					return bytes.NewBuffer(make([]byte, 0, size))
+				},
+			}
+			b.ReportAllocs()
+			b.RunParallel(func(pb *testing.PB) {
+				for pb.Next() {
+					buf := pool.Get().(*bytes.Buffer)
+					buf.Write(data)
+					buf.Reset()
+					pool.Put(buf)
+				}
+			})
+		})
+
+		b.Run(fmt.Sprintf("SizedBufferPool/%d", size), func(b *testing.B) {
+			pool := NewSizedBufferPool(64, size)
+			b.ReportAllocs()
+			b.RunParallel(func(pb *testing.PB) {
+				for pb.Next() {
+					buf := pool.Get()
+					buf.Write(data)
+					pool.Put(buf)
+				}
+			})
+		})
+	}
+}
+
+// BenchmarkHeadroom writes slightly more than the pool's capacity into each
+// buffer, with buffers grown beyond the headroom being replaced on Put, to
+// show the reallocations WithHeadroom saves.
+func BenchmarkHeadroom(b *testing.B) {
+	const size = 16 << 10
+	data := make([]byte, size+size/10)
+	for _, headroom := range []float64{0, 1.25} {
+		b.Run(fmt.Sprint(headroom), func(b *testing.B) {
+			pool := NewSizedBufferPoolWithOptions(WithSize(64), WithAlloc(size),
+				WithMaxCap(size+size/4), WithHeadroom(headroom))
+			b.ReportAllocs()
+			b.RunParallel(func(pb *testing.PB) {
+				for pb.Next() {
+					buf := pool.Get()
+					buf.Write(data)
+					pool.Put(buf)
+				}
+			})
+		})
+	}
+}
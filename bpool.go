@@ -4,3 +4,30 @@ channels.  It is based on the leaky buffer example from the Effective Go
 documentation: http://golang.org/doc/effective_go.html#leaky_buffer
 */
 package bpool
+
+import (
+	"bytes"
+)
+
+// BufferPooler is implemented by the pools of bytes.Buffers in this package.
+// Code that only needs to get and put buffers can depend on it rather than a
+// concrete pool, and use NopPool to disable pooling in tests or debugging.
+//
+// The name BufferPool is taken by the concrete BufferPool type.
+type BufferPooler interface {
+	Get() *bytes.Buffer
+	Put(b *bytes.Buffer)
+}
+
+var (
+	_ BufferPooler = (*BufferPool)(nil)
+	_ BufferPooler = (*SizedBufferPool)(nil)
+	_ BufferPooler = (*ShardedBufferPool)(nil)
+	_ BufferPooler = (*PerPBufferPool)(nil)
+	_ BufferPooler = (*TieredBufferPool)(nil)
+	_ BufferPooler = (*SyncBufferPool)(nil)
+	_ BufferPooler = (*WeightedBufferPool)(nil)
+	_ BufferPooler = (*UnsyncBufferPool)(nil)
+	_ BufferPooler = NopPool{}
+	_ BufferPooler = (*RecordingPool)(nil)
+)
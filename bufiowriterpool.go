@@ -0,0 +1,76 @@
+package bpool
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"sync"
+)
+
+// BufioWriterPool implements a pool of bufio.Writers, each writing into a
+// Buffer from a SizedBufferPool. Both the writers and their buffers are
+// recycled.
+type BufioWriterPool struct {
+	writers *Pool[*bufio.Writer]
+	buffers *SizedBufferPool
+
+	mu    sync.Mutex
+	inner map[*bufio.Writer]*bytes.Buffer
+}
+
+// NewBufioWriterPool creates a new BufioWriterPool retaining up to size
+// writers of bufSize bytes, writing into buffers with an initial capacity of
+// alloc.
+func NewBufioWriterPool(size int, bufSize int, alloc int) (wp *BufioWriterPool) {
+	return &BufioWriterPool{
+		writers: NewPool(size,
+			func() *bufio.Writer {
+				return bufio.NewWriterSize(io.Discard, bufSize)
+			},
+			func(w *bufio.Writer) {
+				w.Reset(io.Discard)
+			}),
+		buffers: NewSizedBufferPool(size, alloc),
+		inner:   make(map[*bufio.Writer]*bytes.Buffer),
+	}
+}
+
+// Get gets a bufio.Writer from the BufioWriterPool, writing into an empty
+// pooled Buffer.
+func (wp *BufioWriterPool) Get() *bufio.Writer {
+	b := wp.buffers.Get()
+	w := wp.writers.Get()
+	w.Reset(b)
+
+	wp.mu.Lock()
+	wp.inner[w] = b
+	wp.mu.Unlock()
+	return w
+}
+
+// Bytes flushes w and returns the bytes written to it so far. The slice is
+// only valid until w is returned with Put.
+func (wp *BufioWriterPool) Bytes(w *bufio.Writer) ([]byte, error) {
+	wp.mu.Lock()
+	b := wp.inner[w]
+	wp.mu.Unlock()
+	if b == nil {
+		return nil, nil
+	}
+	if err := w.Flush(); err != nil {
+		return nil, err
+	}
+	return b.Bytes(), nil
+}
+
+// Put returns w and its Buffer to the BufioWriterPool. Any data still
+// buffered in w is discarded.
+func (wp *BufioWriterPool) Put(w *bufio.Writer) {
+	wp.mu.Lock()
+	b := wp.inner[w]
+	delete(wp.inner, w)
+	wp.mu.Unlock()
+
+	wp.writers.Put(w)
+	wp.buffers.Put(b)
+}
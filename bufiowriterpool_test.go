@@ -0,0 +1,31 @@
+package bpool
+
+import (
+	"testing"
+)
+
+func TestBufioWriterPool(t *testing.T) {
+	wp := NewBufioWriterPool(1, 16, 64)
+
+	w := wp.Get()
+	w.WriteString("first")
+	if b, err := wp.Bytes(w); err != nil || string(b) != "first" {
+		t.Fatalf("Bytes invalid: got %q, %v want %q", b, err, "first")
+	}
+	w.WriteString(" unflushed")
+	wp.Put(w)
+
+	// The recycled writer starts over with an empty buffer.
+	w2 := wp.Get()
+	if w2 != w {
+		t.Fatalf("writer not recycled")
+	}
+	if w2.Buffered() != 0 {
+		t.Fatalf("recycled writer not reset: got %v buffered bytes", w2.Buffered())
+	}
+	w2.WriteString("second")
+	if b, err := wp.Bytes(w2); err != nil || string(b) != "second" {
+		t.Fatalf("data bled across Get cycles: got %q, %v want %q", b, err, "second")
+	}
+	wp.Put(w2)
+}
@@ -0,0 +1,47 @@
+package bpool
+
+// BytesPool implements a leaky pool of []byte in the form of a bounded
+// channel. Unlike BytePool, the slices it hands out are empty with a
+// pre-allocated capacity, ready to be appended to.
+type BytesPool struct {
+	c chan []byte
+	a int
+}
+
+// NewBytesPool creates a new BytesPool bounded to the given size, with new
+// slices allocated with a capacity of alloc.
+func NewBytesPool(size int, alloc int) (bp *BytesPool) {
+	return &BytesPool{
+		c: make(chan []byte, size),
+		a: alloc,
+	}
+}
+
+// Get gets a zero-length []byte from the BytesPool, or creates a new one if
+// none are available in the pool.
+func (bp *BytesPool) Get() (b []byte) {
+	select {
+	case b = <-bp.c:
+	// reuse existing slice
+	default:
+		// create new slice
+		b = make([]byte, 0, bp.a)
+	}
+	return
+}
+
+// Put returns the given []byte to the BytesPool. The slice is truncated to
+// zero length before it is retained.
+func (bp *BytesPool) Put(b []byte) {
+	select {
+	case bp.c <- b[:0]:
+		// slice went back into pool
+	default:
+		// slice didn't go back into pool, just discard
+	}
+}
+
+// Cap returns the capacity of new slices allocated by this pool.
+func (bp *BytesPool) Cap() (n int) {
+	return bp.a
+}
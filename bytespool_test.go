@@ -0,0 +1,55 @@
+package bpool
+
+import "testing"
+
+func TestBytesPool(t *testing.T) {
+	var size int = 4
+	var capacity int = 64
+
+	bufPool := NewBytesPool(size, capacity)
+
+	// Check that retrieved slices are empty with the expected capacity.
+	b := bufPool.Get()
+	if len(b) != 0 || cap(b) != capacity {
+		t.Fatalf("bytespool slice invalid: got len %v cap %v want len %v cap %v",
+			len(b), cap(b), 0, capacity)
+	}
+
+	// Check that returned slices are truncated.
+	bufPool.Put(append(b, "hello"...))
+	if b = bufPool.Get(); len(b) != 0 {
+		t.Fatalf("bytespool slice not truncated: got %v want %v", len(b), 0)
+	}
+
+	// Fill the pool beyond the capped pool size.
+	for i := 0; i < size*2; i++ {
+		bufPool.Put(make([]byte, 0, capacity))
+	}
+
+	// Check the size of the pool.
+	if len(bufPool.c) != size {
+		t.Fatalf("bytespool size invalid: got %v want %v", len(bufPool.c), size)
+	}
+}
+
+var payload = make([]byte, 1024)
+
+func BenchmarkBytesPool(b *testing.B) {
+	bufPool := NewBytesPool(4, len(payload))
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		buf := bufPool.Get()
+		buf = append(buf, payload...)
+		bufPool.Put(buf)
+	}
+}
+
+func BenchmarkBytesPoolSizedBufferPool(b *testing.B) {
+	bufPool := NewSizedBufferPool(4, len(payload))
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		buf := bufPool.Get()
+		buf.Write(payload)
+		bufPool.Put(buf)
+	}
+}
@@ -0,0 +1,75 @@
+package bpool
+
+import (
+	"bytes"
+	"context"
+)
+
+// Close shuts the pool down. Idle buffers are released, buffers passed to
+// Put are discarded from then on, and GetContext and TryGet fail: blocked and
+// subsequent GetContext calls return ErrPoolClosed. Get and its variants,
+// which cannot fail, keep returning newly allocated buffers that the pool
+// discards on Put. Close also stops the goroutines started by WithIdleTTL,
+// WithMinIdle and WithMemoryPressureTrim. It is safe to call Close more than
+// once.
+func (bp *SizedBufferPool) Close() {
+	bp.closeOnce.Do(func() {
+		close(bp.closed)
+	})
+	bp.Stop()
+	bp.StopTrim()
+	bp.Drain()
+}
+
+// CloseAndWait closes the pool like Close, then waits until every buffer
+// handed out has been returned with Put, so that no goroutine still holds
+// pooled memory. If ctx is done first, it returns ctx.Err(); the pool stays
+// closed either way.
+func (bp *SizedBufferPool) CloseAndWait(ctx context.Context) error {
+	bp.Close()
+	for bp.o.Load() > 0 {
+		select {
+		case <-bp.returned:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	// Pass the signal on to other waiters.
+	bp.signalReturned()
+	return nil
+}
+
+// signalReturned wakes up a CloseAndWait waiting for the buffers handed out
+// to be returned.
+func (bp *SizedBufferPool) signalReturned() {
+	select {
+	case bp.returned <- struct{}{}:
+	default:
+	}
+}
+
+// isClosed reports whether Close has been called.
+func (bp *SizedBufferPool) isClosed() bool {
+	select {
+	case <-bp.closed:
+		return true
+	default:
+		return false
+	}
+}
+
+// getBlocking implements Get and its variants when the number of outstanding
+// buffers is limited, blocking until a buffer is available. Once the pool is
+// closed it hands out new buffers instead, which are accounted for like any
+// other miss: they are counted as outstanding, so that Put returning them
+// doesn't release another buffer's count and CloseAndWait waits for them too,
+// and lent, so that WithStrictOwnership accepts them back.
+func (bp *SizedBufferPool) getBlocking(capacity int) *bytes.Buffer {
+	b, err := bp.getLimited(context.Background(), capacity)
+	if err != nil {
+		bp.acquire()
+		bp.miss()
+		return bp.lend(bp.newBuffer(capacity))
+	}
+	return b
+}
@@ -0,0 +1,133 @@
+package bpool
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestSizedBufferPoolCloseWhileBlocked(t *testing.T) {
+	bufPool := NewSizedBufferPool(1, 64)
+	bufPool.Get()
+
+	errs := make(chan error)
+	go func() {
+		_, err := bufPool.GetContext(context.Background())
+		errs <- err
+	}()
+	select {
+	case err := <-errs:
+		t.Fatalf("GetContext on exhausted pool didn't block: got %v", err)
+	case <-time.After(10 * time.Millisecond):
+	}
+
+	bufPool.Close()
+	select {
+	case err := <-errs:
+		if err != ErrPoolClosed {
+			t.Fatalf("blocked GetContext invalid: got %v want %v", err, ErrPoolClosed)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("Close didn't wake blocked GetContext")
+	}
+}
+
+func TestSizedBufferPoolGetAfterClose(t *testing.T) {
+	bufPool := NewSizedBufferPool(2, 64)
+	bufPool.Prefill()
+	bufPool.Close()
+	bufPool.Close()
+
+	if bufPool.Len() != 0 {
+		t.Fatalf("idle buffers retained after Close: got %v want 0", bufPool.Len())
+	}
+	if _, err := bufPool.GetContext(context.Background()); err != ErrPoolClosed {
+		t.Fatalf("GetContext after Close invalid: got %v want %v", err, ErrPoolClosed)
+	}
+	if _, ok := bufPool.TryGet(); ok {
+		t.Fatalf("TryGet after Close succeeded")
+	}
+
+	b := bufPool.Get()
+	if b == nil || b.Cap() != 64 {
+		t.Fatalf("Get after Close invalid: got %v", b)
+	}
+	bufPool.Put(b)
+	if bufPool.Len() != 0 {
+		t.Fatalf("Put after Close retained buffer")
+	}
+
+	limited := NewSizedBufferPoolWithOptions(WithSize(1), WithAlloc(64), WithMaxLive(1))
+	limited.Get()
+	limited.Close()
+	if b := limited.GetGrow(128); b == nil || b.Cap() < 128 {
+		t.Fatalf("limited GetGrow after Close invalid: got %v", b)
+	}
+}
+
+// TestSizedBufferPoolCloseMaxLiveMiss checks that a buffer handed out by a
+// closed pool with a live limit is accounted for as a miss.
+func TestSizedBufferPoolCloseMaxLiveMiss(t *testing.T) {
+	bufPool := NewSizedBufferPoolWithOptions(WithSize(1), WithAlloc(64),
+		WithMaxLive(1), WithStats(true), WithStrictOwnership(true))
+	bufPool.Close()
+	b := bufPool.Get()
+	if s := bufPool.Stats(); s.Gets != 1 || s.Misses != 1 {
+		t.Fatalf("stats after Close invalid: got %v gets and %v misses want 1 and 1",
+			s.Gets, s.Misses)
+	}
+	// Strict ownership panics on a buffer that wasn't lent.
+	bufPool.Put(b)
+}
+
+func TestSizedBufferPoolCloseAndWait(t *testing.T) {
+	bufPool := NewSizedBufferPool(4, 64)
+	b := bufPool.Get()
+
+	// A lagging consumer returns its buffer only later.
+	returned := make(chan struct{})
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		close(returned)
+		bufPool.Put(b)
+	}()
+	if err := bufPool.CloseAndWait(context.Background()); err != nil {
+		t.Fatalf("CloseAndWait invalid: got %v want %v", err, nil)
+	}
+	select {
+	case <-returned:
+	default:
+		t.Fatalf("CloseAndWait returned before the buffer was put back")
+	}
+
+	bufPool = NewSizedBufferPool(4, 64)
+	bufPool.Get()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := bufPool.CloseAndWait(ctx); err != context.DeadlineExceeded {
+		t.Fatalf("CloseAndWait with outstanding buffer invalid: got %v want %v", err,
+			context.DeadlineExceeded)
+	}
+}
+
+func TestSizedBufferPoolCloseAndWaitMaxLive(t *testing.T) {
+	bufPool := NewSizedBufferPoolWithOptions(WithSize(4), WithAlloc(64),
+		WithMaxLive(2))
+	held := bufPool.Get()
+	bufPool.Close()
+
+	// A buffer handed out after Close is counted like the others, so
+	// returning it leaves the held buffer outstanding.
+	bufPool.Put(bufPool.Get())
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := bufPool.CloseAndWait(ctx); err != context.DeadlineExceeded {
+		t.Fatalf("CloseAndWait with held buffer invalid: got %v want %v", err,
+			context.DeadlineExceeded)
+	}
+
+	bufPool.Put(held)
+	if err := bufPool.CloseAndWait(context.Background()); err != nil {
+		t.Fatalf("CloseAndWait after return invalid: got %v want %v", err, nil)
+	}
+}
@@ -0,0 +1,71 @@
+package bpool
+
+import (
+	"bytes"
+)
+
+// WithCoalesce makes the pool pack many small idle buffers into a single
+// backing array. Whenever minBuffers buffers with a capacity of less than
+// targetCap/minBuffers have been retained, the pool takes that many small
+// idle buffers and rebinds them to consecutive regions of one new array of
+// targetCap bytes, releasing their individual arrays.
+//
+// This trades fragmentation for sharing: a workload producing lots of tiny
+// buffers ends up with a few large allocations instead of many small ones,
+// each of which costs heap metadata and garbage collector work. But the
+// shared array stays alive as long as any of its buffers does, and a buffer
+// that outgrows its region is reallocated on its own. Coalescing has no
+// effect together with WithAllocFunc or WithAllocator, and a minBuffers below
+// 2 disables it.
+func WithCoalesce(minBuffers int, targetCap int) Option {
+	return func(o *options) {
+		o.coalesceMin = minBuffers
+		o.coalesceCap = targetCap
+	}
+}
+
+// coalesceRegion returns the capacity of the regions small buffers are packed
+// into, or 0 if coalescing is disabled.
+func (bp *SizedBufferPool) coalesceRegion() int {
+	o := bp.opt
+	if o.coalesceMin < 2 || o.allocFunc != nil || o.allocator != nil {
+		return 0
+	}
+	return o.coalesceCap / o.coalesceMin
+}
+
+// coalesce records a small buffer retained by Put, and packs small idle
+// buffers into a shared array once enough of them were retained.
+func (bp *SizedBufferPool) coalesce(region int) {
+	n := bp.opt.coalesceMin
+	if bp.small.Add(1) < int64(n) {
+		return
+	}
+	bp.small.Store(0)
+
+	// Take up to n small idle buffers, giving the others back.
+	var small, other []*bytes.Buffer
+	for i := bp.idleLen(); i > 0 && len(small) < n; i-- {
+		b, ok := bp.take()
+		if !ok {
+			break
+		}
+		if b.Cap() < region {
+			small = append(small, b)
+		} else {
+			other = append(other, b)
+		}
+	}
+	if len(small) > 1 {
+		slab := make([]byte, len(small)*region)
+		for i, b := range small {
+			*b = *bytes.NewBuffer(slab[i*region : i*region : (i+1)*region])
+			b.Write(bp.opt.template)
+		}
+	}
+	for _, b := range append(small, other...) {
+		if !bp.give(b) {
+			bp.evict(b)
+		}
+	}
+}
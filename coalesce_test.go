@@ -0,0 +1,37 @@
+package bpool
+
+import (
+	"bytes"
+	"slices"
+	"testing"
+	"unsafe"
+)
+
+func TestSizedBufferPoolCoalesce(t *testing.T) {
+	bufPool := NewSizedBufferPoolWithOptions(WithSize(16), WithAlloc(16),
+		WithCoalesce(8, 1024))
+
+	// A burst of tiny buffers, each with its own backing array.
+	for i := 0; i < 8; i++ {
+		bufPool.Put(bytes.NewBuffer(make([]byte, 0, 16)))
+	}
+	if bufPool.Len() != 8 {
+		t.Fatalf("coalesced buffers not retained: got %v want %v", bufPool.Len(), 8)
+	}
+
+	// They now share one array of 1024 bytes, in regions of 128 bytes.
+	var addrs []uintptr
+	for i := 0; i < 8; i++ {
+		b := bufPool.Get()
+		if b.Cap() != 128 {
+			t.Fatalf("coalesced buffer capacity invalid: got %v want %v", b.Cap(), 128)
+		}
+		addrs = append(addrs, uintptr(unsafe.Pointer(unsafe.SliceData(b.Bytes()[:1]))))
+	}
+	slices.Sort(addrs)
+	for i := 1; i < len(addrs); i++ {
+		if addrs[i]-addrs[i-1] != 128 {
+			t.Fatalf("coalesced buffers not packed into one array: got addresses %#x", addrs)
+		}
+	}
+}
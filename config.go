@@ -0,0 +1,123 @@
+package bpool
+
+import (
+	"slices"
+	"time"
+)
+
+// Config describes the configuration of a SizedBufferPool, as reported by
+// Config. Settings that take functions, such as WithOnMiss or WithAllocator,
+// are only reported as being set or not.
+type Config struct {
+	Size  int // number of idle buffers currently retained at most
+	Alloc int // initial capacity of new buffers
+	Cap   int // current capacity of new buffers
+
+	MaxCap          int
+	AbsoluteMaxCap  int
+	RetainTolerance float64
+	MaxLive         int
+
+	Stats       bool
+	AllocTiming bool
+
+	ZeroOnPut       bool
+	ZeroOnGet       bool
+	Poison          bool
+	StrictOwnership bool
+	LeakDetection   bool
+
+	IdleTTL      time.Duration
+	MinIdle      int
+	ShrinkFactor float64
+	MinRetain    time.Duration
+
+	TrimInterval time.Duration
+	TrimFraction float64
+
+	Template   []byte
+	CapBuckets []int
+
+	CustomAlloc   bool // WithAllocFunc or WithAllocator is set
+	Alignment     int
+	GoSizeClasses bool
+	Growth        GrowthStrategy
+	Headroom      float64
+
+	OverflowSize int
+	OverflowCap  int
+	Standby      bool
+
+	CoalesceMin int
+	CoalesceCap int
+
+	AllocThrottle  int
+	AllocRateLimit int
+
+	LIFO           bool
+	AutoMin        int
+	AutoMax        int
+	P95Window      int
+	Tracing        bool
+	DiscardLogging bool
+}
+
+// Config returns the configuration of the pool, for introspection. Unlike
+// Stats, it doesn't change with activity, except for Size and Cap, which
+// follow Resize and SetCap.
+func (bp *SizedBufferPool) Config() Config {
+	o := bp.opt
+	return Config{
+		Size:  bp.idleCap(),
+		Alloc: o.alloc,
+		Cap:   bp.Cap(),
+
+		MaxCap:          bp.m,
+		AbsoluteMaxCap:  o.absoluteMaxCap,
+		RetainTolerance: o.retainTolerance,
+		MaxLive:         o.maxLive,
+
+		Stats:       o.stats,
+		AllocTiming: o.allocTiming,
+
+		ZeroOnPut:       o.zeroOnPut,
+		ZeroOnGet:       o.zeroOnGet,
+		Poison:          o.poison,
+		StrictOwnership: o.strictOwnership,
+		LeakDetection:   o.leakf != nil,
+
+		IdleTTL:      o.idleTTL,
+		MinIdle:      o.minIdle,
+		ShrinkFactor: o.shrinkFactor,
+		MinRetain:    o.minRetain,
+
+		TrimInterval: o.trimInterval,
+		TrimFraction: o.trimFraction,
+
+		Template:   slices.Clone(o.template),
+		CapBuckets: slices.Clone(o.capBuckets),
+
+		CustomAlloc:   o.allocFunc != nil || o.allocator != nil,
+		Alignment:     o.alignment,
+		GoSizeClasses: o.goSizeClasses,
+		Growth:        o.growth,
+		Headroom:      o.headroom,
+
+		OverflowSize: o.overflowSize,
+		OverflowCap:  o.overflowCap,
+		Standby:      o.standby,
+
+		CoalesceMin: o.coalesceMin,
+		CoalesceCap: o.coalesceCap,
+
+		AllocThrottle:  o.allocThrottle,
+		AllocRateLimit: o.allocRate,
+
+		LIFO:           o.lifo,
+		AutoMin:        o.autoMin,
+		AutoMax:        o.autoMax,
+		P95Window:      o.p95Window,
+		Tracing:        o.tracer != nil,
+		DiscardLogging: o.discardLogger != nil,
+	}
+}
@@ -0,0 +1,28 @@
+package bpool
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSizedBufferPoolConfig(t *testing.T) {
+	bufPool := NewSizedBufferPoolWithOptions(WithSize(8), WithAlloc(512),
+		WithMaxCap(4096), WithZeroOnPut(true), WithIdleTTL(time.Minute),
+		WithLIFO(true), WithTemplate([]byte("hdr")),
+		WithGrowthStrategy(GrowExact), WithOverflowPool(2, 0))
+	defer bufPool.Close()
+	bufPool.SetCap(1024)
+
+	got := bufPool.Config()
+	if got.Size != 8 || got.Alloc != 512 || got.Cap != 1024 || got.MaxCap != 4096 {
+		t.Fatalf("sizes invalid: got %+v", got)
+	}
+	if !got.ZeroOnPut || got.IdleTTL != time.Minute || !got.LIFO ||
+		string(got.Template) != "hdr" || got.Growth != GrowExact ||
+		got.OverflowSize != 2 || !got.Stats {
+		t.Fatalf("options not reflected: got %+v", got)
+	}
+	if got.ZeroOnGet || got.CustomAlloc || got.MaxLive != 0 {
+		t.Fatalf("unset options reported: got %+v", got)
+	}
+}
@@ -0,0 +1,40 @@
+package bpool
+
+import (
+	"bytes"
+	"sync/atomic"
+)
+
+// defaultPool is the pool behind the package-level Get and Put, created on
+// first use.
+var defaultPool atomic.Pointer[SizedBufferPool]
+
+// Default returns the pool used by the package-level Get and Put. Unless
+// replaced with SetDefault, it is a SizedBufferPool with the defaults of
+// NewSizedBufferPoolWithOptions, created on first use.
+func Default() *SizedBufferPool {
+	if bp := defaultPool.Load(); bp != nil {
+		return bp
+	}
+	defaultPool.CompareAndSwap(nil, NewSizedBufferPoolWithOptions())
+	return defaultPool.Load()
+}
+
+// SetDefault replaces the pool used by the package-level Get and Put. A nil
+// bp restores a pool with the defaults, created on next use. Buffers from the
+// previous pool can still be returned with Put; they go to the new one.
+func SetDefault(bp *SizedBufferPool) {
+	defaultPool.Store(bp)
+}
+
+// Get gets a Buffer from the default pool. The package-level Get and Put are
+// a convenience for scripts and small tools; code that cares about
+// performance should size its own pool for its workload.
+func Get() *bytes.Buffer {
+	return Default().Get()
+}
+
+// Put returns the given Buffer to the default pool.
+func Put(b *bytes.Buffer) {
+	Default().Put(b)
+}
@@ -0,0 +1,33 @@
+package bpool
+
+import (
+	"testing"
+)
+
+func TestDefaultPool(t *testing.T) {
+	defer SetDefault(nil)
+
+	b := Get()
+	if b.Cap() != defaultAlloc {
+		t.Fatalf("default buffer capacity invalid: got %v want %v", b.Cap(), defaultAlloc)
+	}
+	Put(b)
+	if got := Get(); got != b {
+		t.Fatalf("default pool didn't reuse the buffer")
+	}
+
+	custom := NewSizedBufferPool(2, 64)
+	SetDefault(custom)
+	if Default() != custom {
+		t.Fatalf("default pool not overridden")
+	}
+	Put(Get())
+	if got := custom.Stats(); got.Gets != 1 || got.Puts != 1 {
+		t.Fatalf("package-level calls didn't use the custom pool: got %+v", got)
+	}
+
+	SetDefault(nil)
+	if Default() == custom || Default().Cap() != defaultAlloc {
+		t.Fatalf("default pool not restored")
+	}
+}
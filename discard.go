@@ -0,0 +1,52 @@
+package bpool
+
+// DiscardReason tells why Put threw a buffer away.
+type DiscardReason int
+
+const (
+	// ReasonPoolFull means the pool already held as many idle buffers as it
+	// retains.
+	ReasonPoolFull DiscardReason = iota
+	// ReasonOversized means the buffer had grown past the pool's maximum
+	// capacity.
+	ReasonOversized
+	// ReasonPoolClosed means the pool had been closed.
+	ReasonPoolClosed
+	// ReasonAliased means the buffer was returned with SafePut while its
+	// contents were still in use.
+	ReasonAliased
+)
+
+// String returns the name of the reason.
+func (r DiscardReason) String() string {
+	switch r {
+	case ReasonPoolFull:
+		return "pool full"
+	case ReasonOversized:
+		return "oversized"
+	case ReasonPoolClosed:
+		return "pool closed"
+	case ReasonAliased:
+		return "aliased"
+	}
+	return "unknown"
+}
+
+// WithDiscardLogger sets a function called every time Put throws a buffer
+// away, with the reason and the buffer's capacity. Like WithOnDiscard, it is
+// called synchronously from Put, outside of any lock held by the pool.
+// Buffers that replace aliased or oversized ones and find the pool full are
+// not reported, as they were never handed out.
+func WithDiscardLogger(fn func(reason DiscardReason, cap int)) Option {
+	return func(o *options) {
+		o.discardLogger = fn
+	}
+}
+
+// logDiscard reports a buffer of the given capacity discarded for reason, if
+// a discard logger is set.
+func (bp *SizedBufferPool) logDiscard(reason DiscardReason, capacity int) {
+	if bp.opt.discardLogger != nil {
+		bp.opt.discardLogger(reason, capacity)
+	}
+}
@@ -0,0 +1,31 @@
+package bpool
+
+import (
+	"bytes"
+	"slices"
+	"testing"
+)
+
+func TestSizedBufferPoolDiscardLogger(t *testing.T) {
+	type discard struct {
+		reason DiscardReason
+		cap    int
+	}
+	var got []discard
+	bufPool := NewSizedBufferPoolWithOptions(WithSize(1), WithAlloc(32), WithMaxCap(64),
+		WithDiscardLogger(func(reason DiscardReason, cap int) {
+			got = append(got, discard{reason, cap})
+		}))
+
+	bufPool.Put(bytes.NewBuffer(make([]byte, 0, 32)))
+	bufPool.Put(bytes.NewBuffer(make([]byte, 0, 32)))
+	bufPool.Put(bytes.NewBuffer(make([]byte, 0, 128)))
+
+	want := []discard{{ReasonPoolFull, 32}, {ReasonOversized, 128}}
+	if !slices.Equal(got, want) {
+		t.Fatalf("logged discards invalid: got %v want %v", got, want)
+	}
+	if s := ReasonOversized.String(); s != "oversized" {
+		t.Fatalf("reason name invalid: got %q want %q", s, "oversized")
+	}
+}
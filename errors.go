@@ -0,0 +1,22 @@
+package bpool
+
+import (
+	"errors"
+)
+
+// ErrInvalidConfig is returned when a pool is configured with nonsensical
+// parameters.
+var ErrInvalidConfig = errors.New("bpool: invalid pool configuration")
+
+// ErrPoolClosed is returned when getting a buffer from a pool that has been
+// closed.
+var ErrPoolClosed = errors.New("bpool: pool closed")
+
+// ErrPoolExhausted is returned, wrapping the context's error, when
+// GetContext gives up waiting for one of the buffers handed out to be
+// returned.
+var ErrPoolExhausted = errors.New("bpool: pool exhausted")
+
+// ErrTooLarge is returned when a buffer of the requested capacity cannot be
+// allocated.
+var ErrTooLarge = errors.New("bpool: buffer too large to allocate")
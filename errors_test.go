@@ -0,0 +1,49 @@
+package bpool
+
+import (
+	"context"
+	"errors"
+	"math"
+	"testing"
+)
+
+// TestErrors checks that every error returned by the pool itself can be
+// matched against its sentinel error with errors.Is.
+func TestErrors(t *testing.T) {
+	closed := NewSizedBufferPool(1, 8)
+	closed.Close()
+	exhausted := NewSizedBufferPool(1, 8)
+	exhausted.GetContext(context.Background())
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	for _, tc := range []struct {
+		name string
+		err  func() error
+		want error
+	}{
+		{"GetContext on closed pool", func() error {
+			_, err := closed.GetContext(context.Background())
+			return err
+		}, ErrPoolClosed},
+		{"PrefillContext on closed pool", func() error {
+			return closed.PrefillContext(context.Background())
+		}, ErrPoolClosed},
+		{"GetContext on exhausted pool", func() error {
+			_, err := exhausted.GetContext(cancelled)
+			return err
+		}, ErrPoolExhausted},
+		{"GetGrowE beyond the largest slice", func() error {
+			_, err := NewSizedBufferPool(1, 8).GetGrowE(math.MaxInt)
+			return err
+		}, ErrTooLarge},
+		{"NewSizedBufferPoolE with size 0", func() error {
+			_, err := NewSizedBufferPoolE(0, 8)
+			return err
+		}, ErrInvalidConfig},
+	} {
+		if err := tc.err(); !errors.Is(err, tc.want) {
+			t.Fatalf("%v: got %v want %v", tc.name, err, tc.want)
+		}
+	}
+}
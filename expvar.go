@@ -0,0 +1,23 @@
+package bpool
+
+import (
+	"expvar"
+)
+
+// PublishExpvar publishes the pool's stats under the given expvar name, along
+// with its current capacity and number of idle buffers, e.g.
+//
+//	{"Gets": 10, "Puts": 9, "Misses": 2, "Discards": 0, "OversizeDiscards": 0,
+//	 "PutReplaceAllocs": 0, "UnmatchedPutBytes": 0, "AllocLatencyP50": 0,
+//	 "AllocLatencyP99": 0, "AvgUtilization": 0.25, "Cap": 4096, "Idle": 1}
+//
+// Like expvar.Publish, it panics if the name is already in use.
+func (bp *SizedBufferPool) PublishExpvar(name string) {
+	expvar.Publish(name, expvar.Func(func() any {
+		return struct {
+			Stats
+			Cap  int
+			Idle int
+		}{bp.Stats(), bp.Cap(), bp.Len()}
+	}))
+}
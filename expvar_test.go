@@ -0,0 +1,34 @@
+package bpool
+
+import (
+	"encoding/json"
+	"expvar"
+	"fmt"
+	"testing"
+)
+
+// expvarRuns makes the published names unique when tests are run repeatedly,
+// since expvar names can't be unpublished.
+var expvarRuns int
+
+func TestSizedBufferPoolPublishExpvar(t *testing.T) {
+	expvarRuns++
+	name := fmt.Sprintf("bpool_test_%d", expvarRuns)
+	bufPool := NewSizedBufferPool(2, 64)
+	bufPool.PublishExpvar(name)
+	bufPool.Put(bufPool.Get())
+
+	var got map[string]int
+	if err := json.Unmarshal([]byte(expvar.Get(name).String()), &got); err != nil {
+		t.Fatalf("published value not JSON: %v", err)
+	}
+	want := map[string]int{
+		"Gets": 1, "Puts": 1, "Misses": 1, "Discards": 0, "OversizeDiscards": 0,
+		"Cap": 64, "Idle": 1,
+	}
+	for k, v := range want {
+		if n, ok := got[k]; !ok || n != v {
+			t.Fatalf("field %q invalid: got %v (present %v) want %v", k, n, ok, v)
+		}
+	}
+}
@@ -0,0 +1,98 @@
+package bpool
+
+import (
+	"bytes"
+	"math"
+	"testing"
+)
+
+// fuzzMaxCap bounds the capacities the fuzz target actually allocates.
+const fuzzMaxCap = 1 << 16
+
+// fuzzCap maps an arbitrary fuzzed int to a capacity in [0, fuzzMaxCap).
+func fuzzCap(n int) int {
+	n %= fuzzMaxCap
+	if n < 0 {
+		n += fuzzMaxCap
+	}
+	return n
+}
+
+// FuzzSizedBufferPool checks the capacity arithmetic with arbitrary ints, and
+// runs a pool configured from flags through a sequence of Gets and Puts,
+// asserting that buffers are never smaller than requested.
+func FuzzSizedBufferPool(f *testing.F) {
+	for _, n := range []int{0, 1, math.MaxInt, math.MinInt, -1, pageSize, 32769} {
+		f.Add(n, n, n, uint8(0), []byte{0, 1, 2, 3})
+	}
+	f.Add(100, 1<<20, 5000, uint8(0xff), []byte{2, 2, 0, 1, 3, 1, 0})
+
+	f.Fuzz(func(t *testing.T, alloc, putCap, getCap int, flags uint8, ops []byte) {
+		for _, n := range []int{alloc, putCap, getCap} {
+			if r := roundSizeClass(n); r < n || (n > 0 && r <= 0) {
+				t.Fatalf("roundSizeClass(%v) invalid: got %v", n, r)
+			}
+			if n < 0 {
+				continue
+			}
+			if s := addCap(n, math.MaxInt/2); s < n || s < 0 {
+				t.Fatalf("addCap(%v) overflowed: got %v", n, s)
+			}
+			if s := scaleCap(n, 1.5); s < 0 {
+				t.Fatalf("scaleCap(%v) overflowed: got %v", n, s)
+			}
+		}
+
+		opts := []Option{WithSize(4), WithAlloc(fuzzCap(alloc)),
+			WithMaxCap(fuzzMaxCap)}
+		if flags&1 != 0 {
+			opts = append(opts, WithGoSizeClasses(true))
+		}
+		if flags&2 != 0 {
+			opts = append(opts, WithAlignment(64))
+		}
+		if flags&4 != 0 {
+			opts = append(opts, WithHeadroom(1.25))
+		}
+		if flags&8 != 0 {
+			opts = append(opts, WithRetainTolerance(2))
+		}
+		if flags&16 != 0 {
+			opts = append(opts, WithLIFO(true))
+		}
+		opts = append(opts, WithGrowthStrategy(GrowthStrategy(flags>>5%3)))
+		bufPool := NewSizedBufferPoolWithOptions(opts...)
+
+		var held []*bytes.Buffer
+		for _, op := range ops {
+			switch op % 4 {
+			case 0:
+				b := bufPool.Get()
+				if b.Cap() < bufPool.Cap() {
+					t.Fatalf("cap of Get invalid: got %v want at least %v", b.Cap(), bufPool.Cap())
+				}
+				held = append(held, b)
+			case 1:
+				n := fuzzCap(getCap)
+				b := bufPool.GetGrow(n)
+				if b.Cap() < n {
+					t.Fatalf("cap of GetGrow(%v) invalid: got %v", n, b.Cap())
+				}
+				held = append(held, b)
+			case 2:
+				bufPool.Put(bytes.NewBuffer(make([]byte, 0, fuzzCap(putCap))))
+			case 3:
+				if len(held) > 0 {
+					bufPool.Put(held[len(held)-1])
+					held = held[:len(held)-1]
+				}
+			}
+			if n := bufPool.Len(); n < 0 || n > 4 {
+				t.Fatalf("len invalid: got %v want within [0, 4]", n)
+			}
+			if c := bufPool.Cap(); c < 0 {
+				t.Fatalf("capacity invalid: got %v", c)
+			}
+		}
+	})
+}
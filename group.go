@@ -0,0 +1,41 @@
+package bpool
+
+import (
+	"sync"
+)
+
+// Group manages a set of SizedBufferPools by name, creating each pool the
+// first time it is asked for. The zero value is an empty Group ready to use,
+// and a Group is safe for concurrent use.
+type Group struct {
+	mu    sync.Mutex
+	pools map[string]*SizedBufferPool
+}
+
+// Pool returns the pool with the given name, creating it with
+// NewSizedBufferPool(size, alloc) if it doesn't exist yet. Once a pool has
+// been created, size and alloc are ignored.
+func (g *Group) Pool(name string, size, alloc int) *SizedBufferPool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if bp, ok := g.pools[name]; ok {
+		return bp
+	}
+	if g.pools == nil {
+		g.pools = make(map[string]*SizedBufferPool)
+	}
+	bp := NewSizedBufferPool(size, alloc)
+	g.pools[name] = bp
+	return bp
+}
+
+// GroupStats returns the stats of every pool in the group, keyed by name.
+func (g *Group) GroupStats() map[string]Stats {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	stats := make(map[string]Stats, len(g.pools))
+	for name, bp := range g.pools {
+		stats[name] = bp.Stats()
+	}
+	return stats
+}
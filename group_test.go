@@ -0,0 +1,58 @@
+package bpool
+
+import (
+	"sync"
+	"testing"
+)
+
+func TestGroupConcurrentPool(t *testing.T) {
+	var g Group
+	const n = 16
+	pools := make([]*SizedBufferPool, n)
+	var wg sync.WaitGroup
+	for i := range pools {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			pools[i] = g.Pool("bodies", 4, 64)
+		}(i)
+	}
+	wg.Wait()
+
+	for i, bp := range pools {
+		if bp != pools[0] {
+			t.Fatalf("pool %d is a different instance", i)
+		}
+	}
+	if bp := g.Pool("bodies", 8, 128); bp != pools[0] || bp.Cap() != 64 {
+		t.Fatalf("existing pool not returned: got cap %v want %v", bp.Cap(), 64)
+	}
+	if g.Pool("headers", 4, 64) == pools[0] {
+		t.Fatalf("different names share a pool")
+	}
+}
+
+func TestGroupStats(t *testing.T) {
+	var g Group
+	if got := g.GroupStats(); len(got) != 0 {
+		t.Fatalf("empty group stats invalid: got %v", got)
+	}
+
+	bodies := g.Pool("bodies", 4, 64)
+	bodies.Put(bodies.Get())
+	g.Pool("headers", 4, 64).Get()
+
+	got := g.GroupStats()
+	want := map[string]Stats{
+		"bodies":  {Gets: 1, Puts: 1, Misses: 1},
+		"headers": {Gets: 1, Misses: 1},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("group stats invalid: got %+v want %+v", got, want)
+	}
+	for name, s := range want {
+		if got[name] != s {
+			t.Fatalf("stats of %q invalid: got %+v want %+v", name, got[name], s)
+		}
+	}
+}
@@ -0,0 +1,54 @@
+package bpool
+
+import (
+	"bytes"
+)
+
+// GrowthStrategy determines how an idle buffer smaller than the pool's
+// capacity is grown when it is handed out.
+type GrowthStrategy int
+
+const (
+	// GrowDouble grows the buffer with bytes.Buffer.Grow, which at least
+	// doubles its capacity, trading memory for fewer reallocations by later
+	// writes. It is the default.
+	GrowDouble GrowthStrategy = iota
+	// GrowExact reallocates the buffer with exactly the pool's capacity.
+	GrowExact
+	// GrowSizeClass reallocates the buffer with the pool's capacity rounded
+	// up to the Go runtime's size class, so that no memory is wasted.
+	GrowSizeClass
+)
+
+// WithGrowthStrategy sets how idle buffers smaller than the pool's capacity,
+// e.g. ones that didn't come from the pool or predate a SetCap, are grown
+// when handed out.
+func WithGrowthStrategy(s GrowthStrategy) Option {
+	return func(o *options) {
+		o.growth = s
+	}
+}
+
+// grow grows b to at least capacity bytes according to the pool's growth
+// strategy.
+func (bp *SizedBufferPool) grow(b *bytes.Buffer, capacity int) {
+	switch bp.opt.growth {
+	case GrowExact:
+		bp.regrow(b, capacity)
+	case GrowSizeClass:
+		bp.regrow(b, roundSizeClass(capacity))
+	default:
+		b.Grow(capacity - b.Len())
+	}
+}
+
+// regrow replaces the backing array of b with a new one of the given
+// capacity, keeping b's contents and identity.
+func (bp *SizedBufferPool) regrow(b *bytes.Buffer, capacity int) {
+	nb := bp.newBuffer(capacity)
+	nb.Reset()
+	nb.Write(b.Bytes())
+	bp.free(b)
+	*b = *nb
+	bp.moveArray(bp, nb, b)
+}
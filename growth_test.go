@@ -0,0 +1,31 @@
+package bpool
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestSizedBufferPoolGrowthStrategy(t *testing.T) {
+	for _, tc := range []struct {
+		strategy GrowthStrategy
+		min, max int
+	}{
+		{GrowDouble, 100, 128},
+		{GrowExact, 100, 100},
+		{GrowSizeClass, 112, 112},
+	} {
+		bufPool := NewSizedBufferPoolWithOptions(WithSize(1), WithAlloc(100),
+			WithGrowthStrategy(tc.strategy))
+		small := bytes.NewBuffer(make([]byte, 0, 8))
+		bufPool.Put(small)
+
+		b := bufPool.Get()
+		if b != small {
+			t.Fatalf("strategy %v: idle buffer not reused", tc.strategy)
+		}
+		if b.Cap() < tc.min || b.Cap() > tc.max {
+			t.Fatalf("strategy %v: grown capacity invalid: got %v want %v to %v",
+				tc.strategy, b.Cap(), tc.min, tc.max)
+		}
+	}
+}
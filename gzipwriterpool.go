@@ -0,0 +1,87 @@
+package bpool
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"sync"
+)
+
+// GzipWriterPool implements a pool of gzip.Writers, so that their compression
+// state is only allocated once. Writers either compress into a destination
+// given to Get, or into a Buffer from a SizedBufferPool with GetBuffered.
+type GzipWriterPool struct {
+	writers *Pool[*gzip.Writer]
+	buffers *SizedBufferPool
+
+	mu    sync.Mutex
+	inner map[*gzip.Writer]*bytes.Buffer
+}
+
+// NewGzipWriterPool creates a new GzipWriterPool retaining up to size
+// writers, compressing into buffers with an initial capacity of alloc when
+// obtained with GetBuffered.
+func NewGzipWriterPool(size int, alloc int) (gp *GzipWriterPool) {
+	return &GzipWriterPool{
+		writers: NewPool(size,
+			func() *gzip.Writer {
+				return gzip.NewWriter(io.Discard)
+			},
+			func(w *gzip.Writer) {
+				w.Reset(io.Discard)
+			}),
+		buffers: NewSizedBufferPool(size, alloc),
+		inner:   make(map[*gzip.Writer]*bytes.Buffer),
+	}
+}
+
+// Get gets a gzip.Writer from the GzipWriterPool, compressing into dst. The
+// writer must be closed to complete the stream before it is returned with
+// Put.
+func (gp *GzipWriterPool) Get(dst io.Writer) *gzip.Writer {
+	w := gp.writers.Get()
+	w.Reset(dst)
+	return w
+}
+
+// GetBuffered gets a gzip.Writer from the GzipWriterPool, compressing into an
+// empty pooled Buffer. Use Bytes to get the compressed output.
+func (gp *GzipWriterPool) GetBuffered() *gzip.Writer {
+	b := gp.buffers.Get()
+	w := gp.Get(b)
+
+	gp.mu.Lock()
+	gp.inner[w] = b
+	gp.mu.Unlock()
+	return w
+}
+
+// Bytes closes w, which must have been obtained with GetBuffered, and returns
+// the compressed stream. The slice is only valid until w is returned with
+// Put.
+func (gp *GzipWriterPool) Bytes(w *gzip.Writer) ([]byte, error) {
+	gp.mu.Lock()
+	b := gp.inner[w]
+	gp.mu.Unlock()
+	if b == nil {
+		return nil, nil
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return b.Bytes(), nil
+}
+
+// Put returns w, and its Buffer if it was obtained with GetBuffered, to the
+// GzipWriterPool. Any data not yet flushed by w is discarded.
+func (gp *GzipWriterPool) Put(w *gzip.Writer) {
+	gp.mu.Lock()
+	b, ok := gp.inner[w]
+	delete(gp.inner, w)
+	gp.mu.Unlock()
+
+	gp.writers.Put(w)
+	if ok {
+		gp.buffers.Put(b)
+	}
+}
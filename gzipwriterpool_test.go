@@ -0,0 +1,49 @@
+package bpool
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"testing"
+)
+
+func gunzip(t *testing.T, data []byte) string {
+	t.Helper()
+	r, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("invalid gzip stream: %v", err)
+	}
+	out, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatalf("invalid gzip stream: %v", err)
+	}
+	return string(out)
+}
+
+func TestGzipWriterPool(t *testing.T) {
+	gp := NewGzipWriterPool(1, 64)
+
+	var dst bytes.Buffer
+	w := gp.Get(&dst)
+	io.WriteString(w, "first payload")
+	w.Close()
+	gp.Put(w)
+	if got := gunzip(t, dst.Bytes()); got != "first payload" {
+		t.Fatalf("compressed output invalid: got %q want %q", got, "first payload")
+	}
+
+	// The recycled writer compresses the second payload on its own.
+	w2 := gp.GetBuffered()
+	if w2 != w {
+		t.Fatalf("writer not recycled")
+	}
+	io.WriteString(w2, "second payload")
+	data, err := gp.Bytes(w2)
+	if err != nil {
+		t.Fatalf("Bytes failed: %v", err)
+	}
+	if got := gunzip(t, data); got != "second payload" {
+		t.Fatalf("recycled writer output invalid: got %q want %q", got, "second payload")
+	}
+	gp.Put(w2)
+}
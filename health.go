@@ -0,0 +1,38 @@
+package bpool
+
+import (
+	"fmt"
+)
+
+// healthMinGets is the number of Gets HealthHint needs before it judges the
+// pool, so that a cold start isn't mistaken for misconfiguration.
+const healthMinGets = 100
+
+// HealthHint returns a diagnostic if the pool's stats show that it is
+// misconfigured to the point of doing little but adding overhead, e.g.
+// "pool exhausted: hit rate 2%, consider increasing size", or an empty string
+// if they don't, or too few Gets were made to tell. It relies on Stats, so it
+// reports nothing for a pool created with WithStats(false).
+func (bp *SizedBufferPool) HealthHint() string {
+	s := bp.Stats()
+	if s.Gets < healthMinGets {
+		return ""
+	}
+	if hits := s.Gets - min(s.Misses, s.Gets); hits*10 < s.Gets {
+		return fmt.Sprintf("pool exhausted: hit rate %d%%, consider increasing size",
+			hits*100/s.Gets)
+	}
+	if s.Puts > 0 && s.OversizeDiscards*2 > s.Puts {
+		return fmt.Sprintf("buffers oversized: %d%% of Puts exceed the maximum capacity, consider raising it",
+			s.OversizeDiscards*100/s.Puts)
+	}
+	if s.Puts > 0 && s.Discards*2 > s.Puts {
+		return fmt.Sprintf("pool full: %d%% of Puts discarded, consider increasing size",
+			s.Discards*100/s.Puts)
+	}
+	if size, peak := bp.idleCap(), bp.Peak(); size >= 10 && peak*10 < size {
+		return fmt.Sprintf("pool oversized: %d%% idle, consider decreasing size",
+			100-peak*100/size)
+	}
+	return ""
+}
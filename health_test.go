@@ -0,0 +1,48 @@
+package bpool
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestSizedBufferPoolHealthHint(t *testing.T) {
+	// Gets that never find an idle buffer.
+	exhausted := NewSizedBufferPool(1, 64)
+	for i := 0; i < 100; i++ {
+		exhausted.Get()
+	}
+
+	// Far more idle slots than buffers ever in use.
+	oversized := NewSizedBufferPool(100, 64)
+	for i := 0; i < 200; i++ {
+		oversized.Put(oversized.Get())
+	}
+
+	// Buffers too large to retain.
+	large := NewSizedBufferPoolWithMax(4, 64, 128)
+	for i := 0; i < 100; i++ {
+		large.Get()
+		large.Put(bytes.NewBuffer(make([]byte, 0, 1024)))
+	}
+
+	healthy := NewSizedBufferPool(4, 64)
+	for i := 0; i < 100; i++ {
+		healthy.PutN(healthy.GetN(4))
+	}
+
+	for _, tc := range []struct {
+		name string
+		pool *SizedBufferPool
+		want string
+	}{
+		{"exhausted", exhausted, "pool exhausted: hit rate 0%, consider increasing size"},
+		{"oversized", oversized, "pool oversized: 99% idle, consider decreasing size"},
+		{"large", large, "buffers oversized: 100% of Puts exceed the maximum capacity, consider raising it"},
+		{"healthy", healthy, ""},
+		{"cold", NewSizedBufferPool(1, 64), ""},
+	} {
+		if got := tc.pool.HealthHint(); got != tc.want {
+			t.Fatalf("%s pool hint invalid: got %q want %q", tc.name, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,27 @@
+package bpool
+
+import (
+	"sort"
+)
+
+// recordCap counts a returned buffer of the given capacity in its histogram
+// bucket.
+func (bp *SizedBufferPool) recordCap(capacity int) {
+	i := sort.SearchInts(bp.opt.capBuckets, capacity)
+	bp.hist[i].Add(1)
+}
+
+// CapHistogram returns the number of buffers returned with Put per capacity
+// bucket configured by WithCapHistogram, with one extra final bucket for
+// capacities above the largest bound. It returns nil if no histogram was
+// configured.
+func (bp *SizedBufferPool) CapHistogram() []uint64 {
+	if bp.hist == nil {
+		return nil
+	}
+	counts := make([]uint64, len(bp.hist))
+	for i := range bp.hist {
+		counts[i] = bp.hist[i].Load()
+	}
+	return counts
+}
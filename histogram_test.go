@@ -0,0 +1,25 @@
+package bpool
+
+import (
+	"bytes"
+	"slices"
+	"testing"
+)
+
+func TestSizedBufferPoolCapHistogram(t *testing.T) {
+	bufPool := NewSizedBufferPoolWithOptions(WithSize(1),
+		WithCapHistogram([]int{4096, 1024}))
+
+	for _, capacity := range []int{0, 1024, 1025, 4096, 4097, 1 << 20} {
+		bufPool.Put(bytes.NewBuffer(make([]byte, 0, capacity)))
+	}
+
+	want := []uint64{2, 2, 2}
+	if got := bufPool.CapHistogram(); !slices.Equal(got, want) {
+		t.Fatalf("histogram invalid: got %v want %v", got, want)
+	}
+
+	if got := NewSizedBufferPool(1, 64).CapHistogram(); got != nil {
+		t.Fatalf("histogram without buckets: got %v want nil", got)
+	}
+}
@@ -0,0 +1,104 @@
+package bpool
+
+import (
+	"bytes"
+	"time"
+)
+
+// markIdle records that b is about to be returned to the pool and starts the
+// reaper if it isn't running yet.
+func (bp *SizedBufferPool) markIdle(b *bytes.Buffer) {
+	bp.idleMu.Lock()
+	bp.idle[b] = bp.now()
+	bp.idleMu.Unlock()
+
+	bp.reaper.Do(func() {
+		go bp.reap()
+	})
+}
+
+// forget stops tracking b as an idle buffer, if idle TTLs are in use.
+func (bp *SizedBufferPool) forget(b *bytes.Buffer) {
+	if bp.idle == nil {
+		return
+	}
+	bp.idleMu.Lock()
+	delete(bp.idle, b)
+	bp.idleMu.Unlock()
+}
+
+// reap evicts expired buffers every TTL until the pool is stopped.
+func (bp *SizedBufferPool) reap() {
+	ticker := time.NewTicker(bp.opt.idleTTL)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			bp.evictIdle()
+		case <-bp.stop:
+			return
+		}
+	}
+}
+
+// evictIdle removes every buffer that has been idle for longer than the TTL.
+// Buffers that haven't expired yet are put back into the pool.
+func (bp *SizedBufferPool) evictIdle() {
+	now := bp.now()
+	if bp.lifo != nil {
+		bp.lifo.filter(func(b *bytes.Buffer) bool {
+			return !bp.expire(b, now)
+		})
+		return
+	}
+	bp.visitPeeked(func(b *bytes.Buffer) bool {
+		return !bp.expire(b, now)
+	})
+	c := bp.ch()
+	for n := len(c); n > 0; n-- {
+		var b *bytes.Buffer
+		select {
+		case b = <-c:
+		default:
+			return
+		}
+
+		if bp.expire(b, now) {
+			continue
+		}
+
+		select {
+		case c <- b:
+		default:
+			bp.evict(b)
+		}
+	}
+}
+
+// expire reports whether b has been idle for longer than the TTL at now, and
+// stops tracking and frees it if so.
+func (bp *SizedBufferPool) expire(b *bytes.Buffer, now time.Time) bool {
+	bp.idleMu.Lock()
+	since, ok := bp.idle[b]
+	expired := !ok || now.Sub(since) > bp.opt.idleTTL
+	if expired {
+		delete(bp.idle, b)
+	}
+	bp.idleMu.Unlock()
+	if expired {
+		bp.free(b)
+	}
+	return expired
+}
+
+// Stop shuts down the goroutines evicting idle buffers started by WithIdleTTL
+// and keeping idle buffers started by WithMinIdle. The pool remains usable,
+// but idle buffers are no longer expired or topped up.
+func (bp *SizedBufferPool) Stop() {
+	if bp.stop == nil {
+		return
+	}
+	bp.stopOnce.Do(func() {
+		close(bp.stop)
+	})
+}
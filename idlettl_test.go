@@ -0,0 +1,41 @@
+package bpool
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSizedBufferPoolIdleTTL(t *testing.T) {
+	var ttl time.Duration = time.Minute
+
+	bufPool := NewSizedBufferPoolWithOptions(WithSize(4), WithAlloc(64),
+		WithIdleTTL(ttl))
+	defer bufPool.Stop()
+
+	now := time.Unix(0, 0)
+	bufPool.now = func() time.Time {
+		return now
+	}
+
+	a, b := bufPool.Get(), bufPool.Get()
+	bufPool.Put(a)
+	now = now.Add(ttl / 2)
+	bufPool.Put(b)
+
+	// Only the first buffer has been idle for longer than the TTL.
+	now = now.Add(ttl/2 + time.Second)
+	bufPool.evictIdle()
+	if bufPool.Len() != 1 {
+		t.Fatalf("expired buffers not evicted: got %v want %v", bufPool.Len(), 1)
+	}
+	if got := bufPool.Get(); got != b {
+		t.Fatalf("unexpired buffer evicted")
+	}
+
+	now = now.Add(ttl)
+	bufPool.evictIdle()
+	if bufPool.Len() != 0 || len(bufPool.idle) != 0 {
+		t.Fatalf("idle buffers leaked: got %v pooled, %v tracked", bufPool.Len(),
+			len(bufPool.idle))
+	}
+}
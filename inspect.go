@@ -0,0 +1,64 @@
+package bpool
+
+import (
+	"bytes"
+)
+
+// visitIdle calls fn for each buffer currently idle in the pool, including
+// the one moved aside by PeekCap. The buffers are taken out of the channel
+// one at a time and put back right away; one that no longer fits because of
+// concurrent Puts is dropped. In LIFO mode fn is called with the stack
+// locked.
+func (bp *SizedBufferPool) visitIdle(fn func(b *bytes.Buffer)) {
+	if bp.lifo != nil {
+		bp.lifo.filter(func(b *bytes.Buffer) bool {
+			fn(b)
+			return true
+		})
+		return
+	}
+	bp.visitPeeked(func(b *bytes.Buffer) bool {
+		fn(b)
+		return true
+	})
+	c := bp.ch()
+	for n := len(c); n > 0; n-- {
+		var b *bytes.Buffer
+		select {
+		case b = <-c:
+		default:
+			return
+		}
+
+		fn(b)
+
+		select {
+		case c <- b:
+		default:
+			bp.evict(b)
+		}
+	}
+}
+
+// CapBytes returns the total capacity of the idle buffers currently held by
+// the pool, i.e. the memory the pool keeps alive.
+func (bp *SizedBufferPool) CapBytes() (n int) {
+	bp.visitIdle(func(b *bytes.Buffer) {
+		n += b.Cap()
+	})
+	return
+}
+
+// ForEachIdle calls fn with the capacity of each buffer currently idle in the
+// pool. The capacities are snapshotted and the buffers returned to the pool
+// before fn is called, so fn doesn't hold up concurrent Gets and Puts; it may
+// miss buffers that are taken or returned meanwhile.
+func (bp *SizedBufferPool) ForEachIdle(fn func(capacity int)) {
+	var caps []int
+	bp.visitIdle(func(b *bytes.Buffer) {
+		caps = append(caps, b.Cap())
+	})
+	for _, c := range caps {
+		fn(c)
+	}
+}
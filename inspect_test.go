@@ -0,0 +1,40 @@
+package bpool
+
+import (
+	"bytes"
+	"slices"
+	"testing"
+)
+
+func TestSizedBufferPoolCapBytes(t *testing.T) {
+	bufPool := NewSizedBufferPool(4, 64)
+	for _, capacity := range []int{64, 128, 1024} {
+		bufPool.Put(bytes.NewBuffer(make([]byte, 0, capacity)))
+	}
+
+	if got := bufPool.CapBytes(); got != 64+128+1024 {
+		t.Fatalf("CapBytes invalid: got %v want %v", got, 64+128+1024)
+	}
+	if bufPool.Len() != 3 {
+		t.Fatalf("CapBytes removed buffers: got %v want %v", bufPool.Len(), 3)
+	}
+}
+
+func TestSizedBufferPoolForEachIdle(t *testing.T) {
+	bufPool := NewSizedBufferPool(3, 64)
+	want := []int{64, 128, 1024}
+	for _, capacity := range want {
+		bufPool.Put(bytes.NewBuffer(make([]byte, 0, capacity)))
+	}
+
+	var got []int
+	bufPool.ForEachIdle(func(capacity int) {
+		got = append(got, capacity)
+	})
+	if !slices.Equal(got, want) {
+		t.Fatalf("visited capacities invalid: got %v want %v", got, want)
+	}
+	if bufPool.Len() != 3 {
+		t.Fatalf("ForEachIdle removed buffers: got %v want %v", bufPool.Len(), 3)
+	}
+}
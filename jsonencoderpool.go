@@ -0,0 +1,59 @@
+package bpool
+
+import (
+	"bytes"
+	"encoding/json"
+	"sync"
+)
+
+// JSONEncoderPool hands out json.Encoders that write into Buffers from a
+// SizedBufferPool. json.Encoder can't be reset, so every Get creates a fresh
+// encoder; only the buffers are recycled.
+type JSONEncoderPool struct {
+	buffers *SizedBufferPool
+
+	mu    sync.Mutex
+	inner map[*json.Encoder]*bytes.Buffer
+}
+
+// NewJSONEncoderPool creates a new JSONEncoderPool retaining up to size
+// buffers with an initial capacity of alloc.
+func NewJSONEncoderPool(size int, alloc int) (ep *JSONEncoderPool) {
+	return &JSONEncoderPool{
+		buffers: NewSizedBufferPool(size, alloc),
+		inner:   make(map[*json.Encoder]*bytes.Buffer),
+	}
+}
+
+// Get creates a json.Encoder writing into an empty pooled Buffer.
+func (ep *JSONEncoderPool) Get() *json.Encoder {
+	b := ep.buffers.Get()
+	enc := json.NewEncoder(b)
+
+	ep.mu.Lock()
+	ep.inner[enc] = b
+	ep.mu.Unlock()
+	return enc
+}
+
+// Bytes returns the JSON written by enc so far. The slice is only valid
+// until enc is returned with Put.
+func (ep *JSONEncoderPool) Bytes(enc *json.Encoder) []byte {
+	ep.mu.Lock()
+	b := ep.inner[enc]
+	ep.mu.Unlock()
+	if b == nil {
+		return nil
+	}
+	return b.Bytes()
+}
+
+// Put returns the Buffer of enc to the pool. enc must not be used afterwards.
+func (ep *JSONEncoderPool) Put(enc *json.Encoder) {
+	ep.mu.Lock()
+	b := ep.inner[enc]
+	delete(ep.inner, enc)
+	ep.mu.Unlock()
+
+	ep.buffers.Put(b)
+}
@@ -0,0 +1,32 @@
+package bpool
+
+import (
+	"testing"
+)
+
+func TestJSONEncoderPool(t *testing.T) {
+	ep := NewJSONEncoderPool(1, 64)
+
+	enc := ep.Get()
+	if err := enc.Encode(struct{ Name string }{"first"}); err != nil {
+		t.Fatalf("Encode failed: %v", err)
+	}
+	if got, want := string(ep.Bytes(enc)), "{\"Name\":\"first\"}\n"; got != want {
+		t.Fatalf("encoded bytes invalid: got %q want %q", got, want)
+	}
+	ep.Put(enc)
+
+	enc = ep.Get()
+	if err := enc.Encode(struct{ ID int }{2}); err != nil {
+		t.Fatalf("Encode failed: %v", err)
+	}
+	if got, want := string(ep.Bytes(enc)), "{\"ID\":2}\n"; got != want {
+		t.Fatalf("encoded bytes contaminated: got %q want %q", got, want)
+	}
+	ep.Put(enc)
+
+	if ep.buffers.Stats().Misses != 1 {
+		t.Fatalf("buffer not recycled: got %v misses want %v",
+			ep.buffers.Stats().Misses, 1)
+	}
+}
@@ -0,0 +1,25 @@
+package bpool
+
+import (
+	"bytes"
+	"runtime"
+)
+
+// watchLeak arranges for b to be reported as leaked if it is garbage
+// collected before being returned to the pool.
+func (bp *SizedBufferPool) watchLeak(b *bytes.Buffer) {
+	runtime.SetFinalizer(b, bp.leaked)
+}
+
+// unwatchLeak stops watching b once it has been returned to the pool.
+func (bp *SizedBufferPool) unwatchLeak(b *bytes.Buffer) {
+	runtime.SetFinalizer(b, nil)
+}
+
+// leaked reports a buffer that was garbage collected while handed out, and
+// frees the slot it held.
+func (bp *SizedBufferPool) leaked(b *bytes.Buffer) {
+	bp.release()
+	bp.opt.leakf("bpool: buffer of capacity %d was garbage collected "+
+		"without being returned to the pool", b.Cap())
+}
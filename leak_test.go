@@ -0,0 +1,42 @@
+package bpool
+
+import (
+	"runtime"
+	"testing"
+	"time"
+)
+
+func TestSizedBufferPoolLeakDetection(t *testing.T) {
+	// The pool's record of the arrays allocated by an Allocator doesn't keep
+	// leaked buffers reachable.
+	for name, alloc := range map[string]Allocator{"none": nil, "heap": HeapAllocator{}} {
+		leaks := make(chan string, 2)
+		bufPool := NewSizedBufferPoolWithOptions(WithSize(2), WithAlloc(64),
+			WithAllocator(alloc), WithLeakDetection(func(format string, args ...any) {
+				leaks <- format
+			}))
+
+		// A returned buffer is not reported once it is released.
+		bufPool.Put(bufPool.Get())
+		bufPool.Drain()
+		bufPool.Get().WriteString("leaked")
+
+		deadline := time.Now().Add(5 * time.Second)
+		for len(leaks) == 0 {
+			if time.Now().After(deadline) {
+				t.Fatalf("allocator %v: leaked buffer not reported", name)
+			}
+			runtime.GC()
+			time.Sleep(time.Millisecond)
+		}
+		for i := 0; i < 3; i++ {
+			runtime.GC()
+		}
+		if len(leaks) != 1 {
+			t.Fatalf("allocator %v: leaks invalid: got %v want %v", name, len(leaks), 1)
+		}
+		if n := bufPool.o.Load(); n != 0 {
+			t.Fatalf("allocator %v: leaked buffer still outstanding: got %v want 0", name, n)
+		}
+	}
+}
@@ -0,0 +1,186 @@
+package bpool
+
+import (
+	"bytes"
+	"slices"
+	"sync"
+)
+
+// stack holds the idle buffers of a pool created with WithLIFO, the most
+// recently returned one last.
+type stack struct {
+	mu   sync.Mutex
+	bufs []*bytes.Buffer
+	size int
+	// avail is signalled when a buffer is pushed, to wake up a GetContext
+	// waiting for one.
+	avail chan struct{}
+}
+
+func newStack(size int) *stack {
+	return &stack{
+		bufs:  make([]*bytes.Buffer, 0, size),
+		size:  size,
+		avail: make(chan struct{}, 1),
+	}
+}
+
+// push adds b to the stack and reports whether there was room for it.
+func (s *stack) push(b *bytes.Buffer) bool {
+	s.mu.Lock()
+	if len(s.bufs) >= s.size {
+		s.mu.Unlock()
+		return false
+	}
+	s.bufs = append(s.bufs, b)
+	s.mu.Unlock()
+	s.signal()
+	return true
+}
+
+// pop removes the most recently pushed buffer from the stack. If buffers
+// remain, it passes the signal on to the next waiting GetContext.
+func (s *stack) pop() (*bytes.Buffer, bool) {
+	s.mu.Lock()
+	n := len(s.bufs)
+	if n == 0 {
+		s.mu.Unlock()
+		return nil, false
+	}
+	b := s.bufs[n-1]
+	s.bufs[n-1] = nil
+	s.bufs = s.bufs[:n-1]
+	s.mu.Unlock()
+	if n > 1 {
+		s.signal()
+	}
+	return b, true
+}
+
+func (s *stack) signal() {
+	select {
+	case s.avail <- struct{}{}:
+	default:
+	}
+}
+
+// len returns the number of buffers on the stack.
+func (s *stack) len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.bufs)
+}
+
+// filter removes the buffers for which keep returns false, preserving the
+// order of the others.
+func (s *stack) filter(keep func(b *bytes.Buffer) bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	kept := s.bufs[:0]
+	for _, b := range s.bufs {
+		if keep(b) {
+			kept = append(kept, b)
+		}
+	}
+	clear(s.bufs[len(kept):])
+	s.bufs = kept
+}
+
+// resize changes the number of buffers the stack retains, returning the
+// buffers that no longer fit.
+func (s *stack) resize(size int) (dropped []*bytes.Buffer) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.size = size
+	if n := len(s.bufs) - size; n > 0 {
+		// Keep the most recently pushed buffers.
+		dropped = slices.Clone(s.bufs[:n])
+		copy(s.bufs, s.bufs[n:])
+		clear(s.bufs[size:])
+		s.bufs = s.bufs[:size]
+	}
+	return
+}
+
+// empty removes all buffers from the stack at once, returning them.
+func (s *stack) empty() (bufs []*bytes.Buffer) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	bufs = s.bufs
+	s.bufs = make([]*bytes.Buffer, 0, s.size)
+	return
+}
+
+// take removes an idle buffer from the pool, if there is one.
+func (bp *SizedBufferPool) take() (*bytes.Buffer, bool) {
+	if bp.lifo != nil {
+		return bp.lifo.pop()
+	}
+	if b, ok := bp.unpeek(); ok {
+		return b, true
+	}
+	select {
+	case b := <-bp.ch():
+		return b, true
+	default:
+		return nil, false
+	}
+}
+
+// give adds b to the idle buffers of the pool and reports whether there was
+// room for it.
+func (bp *SizedBufferPool) give(b *bytes.Buffer) bool {
+	if bp.lifo != nil {
+		return bp.lifo.push(b)
+	}
+	c := bp.ch()
+	if bp.peeked.Load() == nil {
+		select {
+		case c <- b:
+		default:
+			return false
+		}
+		if bp.peeked.Load() != nil {
+			// PeekCap moved a buffer aside meanwhile.
+			bp.peekMu.Lock()
+			bp.fitPeeked(c)
+			bp.peekMu.Unlock()
+		}
+		return true
+	}
+	// Count the buffer moved aside by PeekCap, so that the pool holds no
+	// more than its size.
+	bp.peekMu.Lock()
+	defer bp.peekMu.Unlock()
+	if bp.peeked.Load() != nil && len(c) >= cap(c)-1 {
+		return false
+	}
+	select {
+	case c <- b:
+		return true
+	default:
+		return false
+	}
+}
+
+// idleLen returns the number of idle buffers held by the pool.
+func (bp *SizedBufferPool) idleLen() int {
+	if bp.lifo != nil {
+		return bp.lifo.len()
+	}
+	n := len(bp.ch())
+	if bp.peeked.Load() != nil {
+		n++
+	}
+	return n
+}
+
+// idleCap returns the number of idle buffers the pool retains at most.
+func (bp *SizedBufferPool) idleCap() int {
+	if bp.lifo != nil {
+		bp.lifo.mu.Lock()
+		defer bp.lifo.mu.Unlock()
+		return bp.lifo.size
+	}
+	return cap(bp.ch())
+}
@@ -0,0 +1,125 @@
+package bpool
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestSizedBufferPoolLIFO(t *testing.T) {
+	bufPool := NewSizedBufferPoolWithOptions(WithSize(3), WithAlloc(64),
+		WithLIFO(true))
+
+	a, b, c, d := bufPool.Get(), bufPool.Get(), bufPool.Get(), bufPool.Get()
+	for _, buf := range []*bytes.Buffer{a, b, c, d} {
+		bufPool.Put(buf)
+	}
+	if bufPool.Len() != 3 {
+		t.Fatalf("pool size exceeded: got %v want %v", bufPool.Len(), 3)
+	}
+
+	// The most recently returned buffer is reused first.
+	for i, want := range []*bytes.Buffer{c, b, a} {
+		if got := bufPool.Get(); got != want {
+			t.Fatalf("Get %d not in LIFO order", i)
+		}
+	}
+	if s := bufPool.String(); s != "SizedBufferPool{idle: 0/3, cap: 64}" {
+		t.Fatalf("String invalid: got %q", s)
+	}
+}
+
+func TestSizedBufferPoolLIFOGetContext(t *testing.T) {
+	bufPool := NewSizedBufferPoolWithOptions(WithSize(1), WithAlloc(64),
+		WithLIFO(true))
+	b := bufPool.Get()
+
+	done := make(chan *bytes.Buffer)
+	go func() {
+		got, _ := bufPool.GetContext(context.Background())
+		done <- got
+	}()
+	select {
+	case <-done:
+		t.Fatalf("GetContext on exhausted pool didn't block")
+	case <-time.After(10 * time.Millisecond):
+	}
+	bufPool.Put(b)
+	if got := <-done; got != b {
+		t.Fatalf("blocked GetContext didn't receive the returned buffer")
+	}
+}
+
+func TestSizedBufferPoolLIFOResize(t *testing.T) {
+	bufPool := NewSizedBufferPoolWithOptions(WithSize(3), WithAlloc(64),
+		WithLIFO(true))
+	a, b, c := bufPool.Get(), bufPool.Get(), bufPool.Get()
+	bufPool.PutN([]*bytes.Buffer{a, b, c})
+
+	// Shrinking keeps the most recently returned buffers.
+	bufPool.Resize(2)
+	if bufPool.Len() != 2 {
+		t.Fatalf("resized pool invalid: got %v idle want %v", bufPool.Len(), 2)
+	}
+	if bufPool.Get() != c || bufPool.Get() != b {
+		t.Fatalf("resize dropped the wrong buffers")
+	}
+
+	bufPool.Resize(4)
+	bufPool.Prefill()
+	if bufPool.Len() != 4 {
+		t.Fatalf("grown pool not filled: got %v want %v", bufPool.Len(), 4)
+	}
+	bufPool.Drain()
+	if bufPool.Len() != 0 {
+		t.Fatalf("pool not drained: got %v", bufPool.Len())
+	}
+}
+
+func TestSizedBufferPoolLIFOIdleTTL(t *testing.T) {
+	ttl := time.Minute
+	bufPool := NewSizedBufferPoolWithOptions(WithSize(4), WithAlloc(64),
+		WithIdleTTL(ttl), WithLIFO(true))
+	defer bufPool.Stop()
+
+	now := time.Unix(0, 0)
+	bufPool.now = func() time.Time {
+		return now
+	}
+
+	a, b := bufPool.Get(), bufPool.Get()
+	bufPool.Put(a)
+	now = now.Add(ttl / 2)
+	bufPool.Put(b)
+
+	now = now.Add(ttl/2 + time.Second)
+	bufPool.evictIdle()
+	if bufPool.Len() != 1 {
+		t.Fatalf("expired buffers not evicted: got %v want %v", bufPool.Len(), 1)
+	}
+	if bufPool.Get() != b {
+		t.Fatalf("unexpired buffer evicted")
+	}
+}
+
+// BenchmarkSizedBufferPoolLIFO compares FIFO and LIFO reuse with more idle
+// buffers than are in use, so that FIFO cycles through all of them.
+func BenchmarkSizedBufferPoolLIFO(b *testing.B) {
+	for _, lifo := range []bool{false, true} {
+		b.Run(fmt.Sprintf("LIFO=%v", lifo), func(b *testing.B) {
+			bufPool := NewSizedBufferPoolWithOptions(WithSize(1024),
+				WithAlloc(64<<10), WithLIFO(lifo))
+			bufPool.Prefill()
+			data := make([]byte, 64<<10)
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				buf := bufPool.Get()
+				buf.Write(data)
+				bufPool.Put(buf)
+			}
+		})
+	}
+}
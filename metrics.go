@@ -0,0 +1,45 @@
+package bpool
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// labelEscaper escapes a label value for the Prometheus text format.
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// WriteMetrics writes the pool's stats to w in the Prometheus text
+// exposition format, labelled with pool="poolName", e.g. to serve them from a
+// /metrics handler without depending on the Prometheus client library:
+//
+//	bpool_gets_total{pool="api"} 10
+//	bpool_misses_total{pool="api"} 2
+//	bpool_discards_total{pool="api"} 0
+//	bpool_idle_buffers{pool="api"} 1
+//	bpool_cap_bytes{pool="api"} 4096
+//
+// Each metric is preceded by its HELP and TYPE lines. The stats are read
+// atomically without locking the pool. Programs using the client library can
+// register the Collector of the bpool/prometheus package instead.
+func (bp *SizedBufferPool) WriteMetrics(w io.Writer, poolName string) error {
+	s := bp.Stats()
+	label := labelEscaper.Replace(poolName)
+	for _, m := range []struct {
+		name, typ, help string
+		value           uint64
+	}{
+		{"bpool_gets_total", "counter", "Calls to Get.", s.Gets},
+		{"bpool_misses_total", "counter", "Gets that had to allocate a new buffer.", s.Misses},
+		{"bpool_discards_total", "counter", "Puts that threw the buffer away.", s.Discards},
+		{"bpool_idle_buffers", "gauge", "Idle buffers held by the pool.", uint64(bp.Len())},
+		{"bpool_cap_bytes", "gauge", "Base capacity of new buffers in bytes.", uint64(bp.Cap())},
+	} {
+		_, err := fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n%s{pool=\"%s\"} %d\n",
+			m.name, m.help, m.name, m.typ, m.name, label, m.value)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
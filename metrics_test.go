@@ -0,0 +1,32 @@
+package bpool
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestSizedBufferPoolWriteMetrics(t *testing.T) {
+	bufPool := NewSizedBufferPool(1, 64)
+	a, b := bufPool.Get(), bufPool.Get()
+	bufPool.Put(a)
+	bufPool.Put(b)
+
+	var out bytes.Buffer
+	if err := bufPool.WriteMetrics(&out, `api "v1"`); err != nil {
+		t.Fatalf("WriteMetrics failed: %v", err)
+	}
+	for _, want := range []string{
+		"# TYPE bpool_gets_total counter\n",
+		`bpool_gets_total{pool="api \"v1\""} 2` + "\n",
+		`bpool_misses_total{pool="api \"v1\""} 2` + "\n",
+		`bpool_discards_total{pool="api \"v1\""} 1` + "\n",
+		"# TYPE bpool_idle_buffers gauge\n",
+		`bpool_idle_buffers{pool="api \"v1\""} 1` + "\n",
+		`bpool_cap_bytes{pool="api \"v1\""} 64` + "\n",
+	} {
+		if !strings.Contains(out.String(), want) {
+			t.Fatalf("metrics missing %q: got\n%s", want, out.String())
+		}
+	}
+}
@@ -0,0 +1,29 @@
+package bpool
+
+// keepWarm tops up the idle buffers to the level set with WithMinIdle right
+// away and after every miss, until the pool is stopped.
+func (bp *SizedBufferPool) keepWarm() {
+	for {
+		bp.topUp()
+		select {
+		case <-bp.warm:
+		case <-bp.stop:
+			return
+		}
+	}
+}
+
+// topUp allocates buffers until the pool holds the number of idle buffers set
+// with WithMinIdle, or as many as its size allows.
+func (bp *SizedBufferPool) topUp() {
+	for bp.idleLen() < min(bp.opt.minIdle, bp.idleCap()) && !bp.isClosed() {
+		b := bp.get()
+		if bp.idle != nil {
+			bp.markIdle(b)
+		}
+		if !bp.give(b) {
+			bp.evict(b)
+			return
+		}
+	}
+}
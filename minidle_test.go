@@ -0,0 +1,33 @@
+package bpool
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSizedBufferPoolMinIdle(t *testing.T) {
+	bufPool := NewSizedBufferPoolWithOptions(WithSize(8), WithAlloc(64),
+		WithMinIdle(4))
+	defer bufPool.Stop()
+
+	waitLen := func(want int) {
+		t.Helper()
+		deadline := time.Now().Add(5 * time.Second)
+		for bufPool.Len() != want {
+			if time.Now().After(deadline) {
+				t.Fatalf("idle buffers invalid: got %v want %v", bufPool.Len(), want)
+			}
+			time.Sleep(time.Millisecond)
+		}
+	}
+	waitLen(4)
+
+	// A miss on the drained pool tops it up again, but no further.
+	bufPool.Drain()
+	bufPool.Get()
+	waitLen(4)
+	time.Sleep(10 * time.Millisecond)
+	if n := bufPool.Len(); n != 4 {
+		t.Fatalf("idle buffers after top-up invalid: got %v want %v", n, 4)
+	}
+}
@@ -0,0 +1,69 @@
+//go:build unix
+
+// Package mmapbuf provides a bpool.Allocator backing pooled buffers with
+// anonymous memory mappings outside of the Go heap, so that large,
+// long-lived pools don't add to the garbage collector's work.
+//
+// Memory is handed back to the operating system when the pool discards a
+// buffer. A buffer that outgrows its capacity is reallocated by bytes.Buffer
+// on the Go heap, and its mapping stays allocated until the pool discards
+// it, so pools using this allocator should bound buffer sizes, e.g. with
+// bpool.WithMaxCap, and keep writes within the pool's capacity.
+package mmapbuf
+
+import (
+	"os"
+	"sync"
+	"syscall"
+	"unsafe"
+
+	"github.com/oxtoacart/bpool"
+)
+
+// Allocator allocates slices from anonymous memory mappings. Mappings are
+// page-aligned and their size is rounded up to a whole number of pages, all
+// of which is made available as capacity. The zero value is ready to use.
+type Allocator struct {
+	mu   sync.Mutex
+	live map[*byte][]byte
+}
+
+var _ bpool.Allocator = (*Allocator)(nil)
+
+// Alloc maps a new region of at least capacity bytes. It panics if the
+// mapping fails, like allocating from the Go heap does when out of memory.
+func (a *Allocator) Alloc(capacity int) []byte {
+	page := os.Getpagesize()
+	size := max((capacity+page-1)/page*page, page)
+	m, err := syscall.Mmap(-1, 0, size, syscall.PROT_READ|syscall.PROT_WRITE,
+		syscall.MAP_ANON|syscall.MAP_PRIVATE)
+	if err != nil {
+		panic(&os.SyscallError{Syscall: "mmap", Err: err})
+	}
+	a.mu.Lock()
+	if a.live == nil {
+		a.live = make(map[*byte][]byte)
+	}
+	a.live[unsafe.SliceData(m)] = m
+	a.mu.Unlock()
+	return m[:0]
+}
+
+// Free unmaps the region b was allocated from. Slices that weren't returned
+// by Alloc are ignored. b must not be used afterwards.
+func (a *Allocator) Free(b []byte) {
+	a.mu.Lock()
+	m, ok := a.live[unsafe.SliceData(b)]
+	delete(a.live, unsafe.SliceData(b))
+	a.mu.Unlock()
+	if ok {
+		syscall.Munmap(m)
+	}
+}
+
+// Live returns the number of mappings currently allocated and not freed.
+func (a *Allocator) Live() int {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return len(a.live)
+}
@@ -0,0 +1,82 @@
+//go:build unix
+
+package mmapbuf
+
+import (
+	"os"
+	"strings"
+	"testing"
+	"unsafe"
+
+	"github.com/oxtoacart/bpool"
+)
+
+func TestAllocator(t *testing.T) {
+	var a Allocator
+	b := a.Alloc(100)
+	page := os.Getpagesize()
+	if len(b) != 0 || cap(b) != page {
+		t.Fatalf("allocation invalid: got len %v cap %v want len 0 cap %v", len(b), cap(b), page)
+	}
+	if addr := uintptr(unsafe.Pointer(unsafe.SliceData(b))); addr%uintptr(page) != 0 {
+		t.Fatalf("allocation not page-aligned: got address %#x", addr)
+	}
+
+	// Foreign slices are ignored.
+	a.Free(make([]byte, 100))
+	if a.Live() != 1 {
+		t.Fatalf("live mappings invalid: got %v want %v", a.Live(), 1)
+	}
+	a.Free(b[:cap(b)])
+	if a.Live() != 0 {
+		t.Fatalf("mapping not freed: got %v live want %v", a.Live(), 0)
+	}
+}
+
+func TestPoolRoundTrip(t *testing.T) {
+	var a Allocator
+	page := os.Getpagesize()
+	bufPool := bpool.NewSizedBufferPoolWithOptions(bpool.WithSize(2),
+		bpool.WithAlloc(page), bpool.WithMaxCap(page), bpool.WithAllocator(&a))
+
+	data := strings.Repeat("x", page/4)
+	bufs := bufPool.GetN(3)
+	for _, b := range bufs {
+		b.WriteString(data)
+	}
+	for _, b := range bufs {
+		if b.String() != data {
+			t.Fatalf("data written to mapped buffer lost")
+		}
+	}
+	bufPool.PutN(bufs)
+	if a.Live() != 2 {
+		t.Fatalf("live mappings after Put invalid: got %v want %v", a.Live(), 2)
+	}
+
+	// The retained buffers are reused and still writable.
+	b := bufPool.Get()
+	b.WriteString(data)
+	if b.String() != data {
+		t.Fatalf("data written to reused buffer lost")
+	}
+	bufPool.Put(b)
+
+	bufPool.Drain()
+	if a.Live() != 0 {
+		t.Fatalf("mappings leaked after Drain: got %v want %v", a.Live(), 0)
+	}
+
+	// A buffer grown onto the heap is replaced by a new mapping and its own
+	// mapping freed.
+	b = bufPool.Get()
+	b.WriteString(strings.Repeat("x", 2*page))
+	bufPool.Put(b)
+	if a.Live() != 1 {
+		t.Fatalf("mapping of grown buffer leaked: got %v live want %v", a.Live(), 1)
+	}
+	bufPool.Drain()
+	if a.Live() != 0 {
+		t.Fatalf("mappings leaked after Drain: got %v want %v", a.Live(), 0)
+	}
+}
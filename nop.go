@@ -0,0 +1,17 @@
+package bpool
+
+import (
+	"bytes"
+)
+
+// NopPool is a BufferPooler that doesn't pool at all: Get always allocates a
+// new Buffer and Put discards it.
+type NopPool struct{}
+
+// Get creates a new, empty Buffer.
+func (NopPool) Get() *bytes.Buffer {
+	return new(bytes.Buffer)
+}
+
+// Put discards the given Buffer.
+func (NopPool) Put(b *bytes.Buffer) {}
@@ -0,0 +1,15 @@
+package bpool
+
+import "testing"
+
+func TestNopPool(t *testing.T) {
+	var pool BufferPooler = NopPool{}
+
+	a := pool.Get()
+	a.WriteString("hello")
+	pool.Put(a)
+
+	if b := pool.Get(); b == a || b.Len() != 0 {
+		t.Fatalf("NopPool reused or returned a dirty buffer")
+	}
+}
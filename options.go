@@ -0,0 +1,415 @@
+package bpool
+
+import (
+	"bytes"
+	"slices"
+	"time"
+)
+
+// Defaults used by NewSizedBufferPoolWithOptions when no Option overrides
+// them.
+const (
+	defaultSize  = 64
+	defaultAlloc = 4096
+)
+
+// options holds the configuration of a SizedBufferPool.
+type options struct {
+	size   int
+	alloc  int
+	maxCap int
+	stats  bool
+
+	zeroOnPut bool
+	zeroOnGet bool
+	poison    bool
+	idleTTL   time.Duration
+	minIdle   int
+
+	strictOwnership bool
+
+	onMiss    func()
+	onDiscard func()
+
+	discardLogger func(reason DiscardReason, cap int)
+
+	template []byte
+
+	capBuckets []int
+
+	shrinkFactor float64
+
+	allocFunc func(capacity int) *bytes.Buffer
+	allocator Allocator
+
+	retainTolerance float64
+	headroom        float64
+
+	allocTiming bool
+
+	minRetain time.Duration
+	putSpin   int
+
+	absoluteMaxCap int
+
+	goSizeClasses bool
+
+	coalesceMin int
+	coalesceCap int
+
+	allocThrottle int
+	allocRate     int
+
+	tracer Tracer
+
+	growth GrowthStrategy
+
+	maxLive int
+
+	overflowSize int
+	overflowCap  int
+	standby      bool
+
+	alignment int
+
+	trimInterval time.Duration
+	trimFraction float64
+	pressure     func() bool
+
+	leakf func(format string, args ...any)
+
+	lifo bool
+
+	autoMin int
+	autoMax int
+
+	p95Window int
+}
+
+// Option configures a SizedBufferPool created by
+// NewSizedBufferPoolWithOptions.
+type Option func(o *options)
+
+// WithSize sets the number of buffers retained in the pool.
+func WithSize(size int) Option {
+	return func(o *options) {
+		o.size = size
+	}
+}
+
+// WithAlloc sets the initial capacity of new buffers.
+func WithAlloc(alloc int) Option {
+	return func(o *options) {
+		o.alloc = alloc
+	}
+}
+
+// WithMaxCap sets the largest buffer capacity retained by the pool. See
+// NewSizedBufferPoolWithMax.
+func WithMaxCap(maxCap int) Option {
+	return func(o *options) {
+		o.maxCap = maxCap
+	}
+}
+
+// WithRetainTolerance makes Put retain buffers whose capacity is at most
+// factor times the pool's current capacity, and replace larger ones like
+// buffers over the limit set with WithMaxCap. Unlike WithMaxCap, the limit
+// follows the pool's capacity when it changes. If both are set, the lower
+// limit applies. A factor of 0 disables the tolerance.
+func WithRetainTolerance(factor float64) Option {
+	return func(o *options) {
+		o.retainTolerance = factor
+	}
+}
+
+// WithHeadroom makes the pool allocate new buffers with factor times its
+// current capacity, so that writes slightly exceeding the capacity don't
+// reallocate. The headroom never exceeds the limit set with WithMaxCap or
+// WithRetainTolerance, so that Put retains such buffers. A factor of at most 1
+// disables the headroom.
+func WithHeadroom(factor float64) Option {
+	return func(o *options) {
+		o.headroom = factor
+	}
+}
+
+// WithPutSpin makes Put retry up to n times, yielding the processor with
+// runtime.Gosched in between, before discarding a buffer because the pool is
+// full, so that a slot about to be freed by a concurrent Get isn't missed.
+// It is cheaper than WithMinRetain, which sleeps, and is tried first if both
+// are set. An n of 0 disables spinning.
+func WithPutSpin(n int) Option {
+	return func(o *options) {
+		o.putSpin = n
+	}
+}
+
+// WithMinRetain makes Put retry for up to d before discarding a buffer
+// because the pool is full, so that a pool that is only full for a moment,
+// e.g. during a burst, doesn't throw away freshly allocated buffers only to
+// allocate new ones right after. Put sleeps with an increasing backoff
+// between retries, so it may then block for up to d. A d of 0 disables
+// retries.
+func WithMinRetain(d time.Duration) Option {
+	return func(o *options) {
+		o.minRetain = d
+	}
+}
+
+// WithAbsoluteMaxCap makes Get check the capacity of every idle buffer it
+// hands out, and replace one larger than maxCap with a new buffer of the
+// pool's capacity. Unlike the limits checked by Put, which may be high at the
+// time a buffer is returned, e.g. under WithRetainTolerance or WithP95Sizing,
+// this guarantees Get never hands out a buffer over maxCap, except from the
+// overflow pool. A maxCap of 0 disables the check.
+func WithAbsoluteMaxCap(maxCap int) Option {
+	return func(o *options) {
+		o.absoluteMaxCap = maxCap
+	}
+}
+
+// WithStats enables or disables collection of the counters reported by
+// Stats. Stats are enabled by default.
+func WithStats(enabled bool) Option {
+	return func(o *options) {
+		o.stats = enabled
+	}
+}
+
+// WithAllocTiming enables or disables measuring how long the pool takes to
+// allocate each new buffer, reported by Stats as AllocLatencyP50 and
+// AllocLatencyP99. Timing is disabled by default, as it reads the clock twice
+// per allocation.
+func WithAllocTiming(enabled bool) Option {
+	return func(o *options) {
+		o.allocTiming = enabled
+	}
+}
+
+// WithZeroOnPut makes Put overwrite the whole backing array of every returned
+// buffer with zeros, whether it is retained or discarded, so that sensitive
+// data doesn't outlive its use. This costs a pass over the full capacity of
+// each buffer on every Put.
+func WithZeroOnPut(enabled bool) Option {
+	return func(o *options) {
+		o.zeroOnPut = enabled
+	}
+}
+
+// WithZeroOnGet makes Get overwrite the whole backing array of every reused
+// buffer with zeros before handing it out, so that stale bytes beyond its
+// length can't be exposed by a caller reading past Len. Unlike WithZeroOnPut
+// it also covers buffers that were put into the pool without being zeroed.
+// New buffers are already zeroed by the Go allocator.
+func WithZeroOnGet(enabled bool) Option {
+	return func(o *options) {
+		o.zeroOnGet = enabled
+	}
+}
+
+// WithDebugPoison makes Put fill the whole backing array of every returned
+// buffer with the byte 0xDE, so that code still using a buffer after
+// returning it reads obviously wrong data and fails loudly. Buffers are
+// handed out empty as usual. Poisoning costs a pass over the full capacity
+// of each buffer on every Put and is meant for debugging; it takes
+// precedence over WithZeroOnPut.
+func WithDebugPoison(enabled bool) Option {
+	return func(o *options) {
+		o.poison = enabled
+	}
+}
+
+// WithIdleTTL makes the pool release buffers that have been idle for longer
+// than ttl, so that memory is given back once a busy period is over. A
+// background goroutine, started on the first Put, evicts expired buffers
+// until the pool is stopped with Stop.
+func WithIdleTTL(ttl time.Duration) Option {
+	return func(o *options) {
+		o.idleTTL = ttl
+	}
+}
+
+// WithMinIdle makes the pool keep at least n idle buffers, as far as its size
+// allows, so that bursts find buffers ready instead of missing. A background
+// goroutine fills the pool up to n right away and tops it up again whenever a
+// Get misses, until the pool is stopped with Stop.
+func WithMinIdle(n int) Option {
+	return func(o *options) {
+		o.minIdle = n
+	}
+}
+
+// WithStrictOwnership makes the pool track every buffer it hands out and
+// panic when Put receives a buffer that didn't come from the pool or that was
+// already returned. Tracking costs a map update on every Get and Put, so this
+// is meant for catching misuse rather than for production.
+func WithStrictOwnership(enabled bool) Option {
+	return func(o *options) {
+		o.strictOwnership = enabled
+	}
+}
+
+// WithOnMiss sets a function called every time Get has to allocate a new
+// buffer, e.g. to alert when the pool is undersized for the current load. It
+// is called synchronously from Get, outside of any lock held by the pool.
+func WithOnMiss(fn func()) Option {
+	return func(o *options) {
+		o.onMiss = fn
+	}
+}
+
+// WithOnDiscard sets a function called every time Put throws a buffer away.
+// It is called synchronously from Put, outside of any lock held by the pool.
+func WithOnDiscard(fn func()) Option {
+	return func(o *options) {
+		o.onDiscard = fn
+	}
+}
+
+// WithTemplate makes every buffer handed out by the pool start with a copy of
+// template, e.g. a fixed protocol header. The template is written when a
+// buffer is allocated and again when it is reset on Put, so callers only have
+// to append the payload.
+func WithTemplate(template []byte) Option {
+	return func(o *options) {
+		o.template = bytes.Clone(template)
+	}
+}
+
+// WithCapHistogram makes Put record the capacity of every returned buffer in
+// a histogram, reported by CapHistogram. buckets holds the inclusive upper
+// bounds of the buckets; capacities above the largest bound are counted in an
+// extra, final bucket. The histogram shows whether the workload would be
+// better served by a TieredBufferPool.
+func WithCapHistogram(buckets []int) Option {
+	return func(o *options) {
+		o.capBuckets = slices.Clone(buckets)
+		slices.Sort(o.capBuckets)
+	}
+}
+
+// WithShrinkFactor lets a pool that stays full give memory back. Each Put
+// that finds the pool full means the idle buffers aren't in demand, so with a
+// probability of factor (between 0 and 1) it also drops one of them. The
+// default of 0 never drops idle buffers this way.
+func WithShrinkFactor(factor float64) Option {
+	return func(o *options) {
+		o.shrinkFactor = min(max(factor, 0), 1)
+	}
+}
+
+// WithAllocFunc makes the pool create every new buffer by calling fn with the
+// desired capacity, instead of allocating from the Go heap. This lets pooled
+// buffers be backed by an arena, a memory-mapped region or an instrumented
+// allocator. fn must return an empty buffer.
+func WithAllocFunc(fn func(capacity int) *bytes.Buffer) Option {
+	return func(o *options) {
+		o.allocFunc = fn
+	}
+}
+
+// WithMaxLive caps the number of buffers handed out by the pool and not yet
+// returned at n, independently of the number of idle buffers the pool
+// retains. Once n buffers are outstanding, Get and its variants block until
+// one is returned, GetContext blocks until then or until its context is done,
+// and TryGet, which never allocates, only succeeds if a buffer is idle. Since
+// the pool only allocates when it has no idle buffer, this also bounds the
+// number of buffers that exist at all.
+func WithMaxLive(n int) Option {
+	return func(o *options) {
+		o.maxLive = n
+	}
+}
+
+// WithOverflowPool keeps up to size buffers that grew past the limit set with
+// WithMaxCap in a secondary pool instead of discarding them on Put, so that
+// occasional large writes can still be served from cache. Buffers larger than
+// capacity are discarded as before; a capacity of 0 accepts any size.
+// GetGrow and GetWithHint requests for more than the pool's capacity are
+// served from the overflow pool first. PutShrunk never uses it.
+func WithOverflowPool(size, capacity int) Option {
+	return func(o *options) {
+		o.overflowSize = size
+		o.overflowCap = capacity
+	}
+}
+
+// WithAlignment makes the pool allocate buffers whose backing array starts at
+// an address that is a multiple of align bytes, e.g. 64 for cache-line or
+// 4096 for page alignment. Each allocation is over-sized by up to align-1
+// bytes to find an aligned offset, so large alignments are costly for small
+// buffers. Only the pool's own allocations are aligned: a buffer that grows
+// past its capacity is reallocated by bytes.Buffer without alignment. An
+// align of 0 or 1 disables alignment. It has no effect together with
+// WithAllocFunc.
+func WithAlignment(align int) Option {
+	return func(o *options) {
+		o.alignment = align
+	}
+}
+
+// WithMemoryPressureTrim makes the pool shed idle buffers when memory is
+// tight. A goroutine calls pressure every interval and, whenever it reports
+// pressure, drops the given fraction of the idle buffers, clamped to [0, 1].
+// HeapAbove provides a signal based on the size of the heap. The goroutine is
+// controlled with StartTrim and StopTrim, and stopped by Close.
+func WithMemoryPressureTrim(interval time.Duration, fraction float64, pressure func() bool) Option {
+	return func(o *options) {
+		o.trimInterval = interval
+		o.trimFraction = min(max(fraction, 0), 1)
+		o.pressure = pressure
+	}
+}
+
+// WithLeakDetection enables a debug mode that reports buffers which are
+// garbage collected without having been returned to the pool, by calling
+// logf with a description of the leak. The slot held by a leaked buffer is
+// freed, so that limits such as WithMaxLive don't shrink over time. Leaks are
+// found with finalizers, which slow down Get and Put and delay collection, so
+// this mode is meant for tracking down leaking call sites rather than for
+// production use. Buffers must be allocated individually, i.e. not be fields
+// of larger structs, and strict ownership mode keeps handed out buffers
+// reachable and so hides their leaks.
+func WithLeakDetection(logf func(format string, args ...any)) Option {
+	return func(o *options) {
+		o.leakf = logf
+	}
+}
+
+// WithLIFO makes the pool hand out the most recently returned idle buffer
+// first, rather than the least recently returned one. The most recently used
+// buffer is the most likely to still be in the CPU caches, at the cost of
+// keeping the idle buffers in a mutex-protected stack instead of a channel.
+func WithLIFO(lifo bool) Option {
+	return func(o *options) {
+		o.lifo = lifo
+	}
+}
+
+// WithAutoResize makes the pool adapt the number of buffers it retains to the
+// demand, between minSize and maxSize. Every 1000 Gets the pool doubles in
+// size if more than a tenth of them had to allocate, or shrinks by the number
+// of buffers that stayed idle throughout if none did. The initial size is
+// clamped to [minSize, maxSize]. Tracking the demand adds a mutex to every
+// Get.
+func WithAutoResize(minSize, maxSize int) Option {
+	return func(o *options) {
+		o.autoMin = minSize
+		o.autoMax = maxSize
+	}
+}
+
+// WithP95Sizing makes the pool size new buffers to the 95th percentile of the
+// capacities of the last window buffers returned with Put, recomputed each
+// time window buffers have been returned. Unlike an average, the percentile
+// isn't pulled up by rare huge buffers, which are better left to grow on
+// their own. The capacity set with WithAlloc is used until the first window
+// is complete and acts as a floor afterwards, and the capacity never exceeds
+// the largest one Put retains, e.g. with WithMaxCap. Buffers returned with
+// PutLen count with their length instead.
+func WithP95Sizing(window int) Option {
+	return func(o *options) {
+		o.p95Window = window
+	}
+}
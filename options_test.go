@@ -0,0 +1,443 @@
+package bpool
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestSizedBufferPoolOptions(t *testing.T) {
+	bufPool := NewSizedBufferPoolWithOptions()
+	if cap(bufPool.ch()) != defaultSize || bufPool.Cap() != defaultAlloc {
+		t.Fatalf("default pool invalid: got size %v alloc %v want size %v alloc %v",
+			cap(bufPool.ch()), bufPool.Cap(), defaultSize, defaultAlloc)
+	}
+
+	bufPool = NewSizedBufferPoolWithOptions(WithSize(2), WithAlloc(128),
+		WithMaxCap(1024), WithStats(false))
+	if cap(bufPool.ch()) != 2 || bufPool.Cap() != 128 || bufPool.m != 1024 {
+		t.Fatalf("configured pool invalid: got size %v alloc %v maxCap %v",
+			cap(bufPool.ch()), bufPool.Cap(), bufPool.m)
+	}
+
+	bufPool.Put(bufPool.Get())
+	if got := bufPool.Stats(); got != (Stats{}) {
+		t.Fatalf("disabled stats collected: got %+v", got)
+	}
+}
+
+func TestSizedBufferPoolZeroOnPut(t *testing.T) {
+	bufPool := NewSizedBufferPoolWithOptions(WithSize(1), WithAlloc(64),
+		WithZeroOnPut(true))
+
+	b := bufPool.Get()
+	b.WriteString("secret")
+	bufPool.Put(b)
+
+	if got := bufPool.Get(); got != b {
+		t.Fatalf("buffer not reused")
+	}
+	for i, c := range b.Bytes()[:b.Cap()] {
+		if c != 0 {
+			t.Fatalf("byte %v not zeroed: got %#x", i, c)
+		}
+	}
+}
+
+func TestSizedBufferPoolHooks(t *testing.T) {
+	var misses, discards int
+
+	bufPool := NewSizedBufferPoolWithOptions(WithSize(1), WithAlloc(64),
+		WithOnMiss(func() { misses++ }),
+		WithOnDiscard(func() { discards++ }))
+
+	a, b := bufPool.Get(), bufPool.Get() // two misses
+	bufPool.Put(a)
+	bufPool.Put(b) // pool full, one discard
+	bufPool.Get()  // hit
+
+	if misses != 2 || discards != 1 {
+		t.Fatalf("hooks invalid: got %v misses %v discards want %v misses %v discards",
+			misses, discards, 2, 1)
+	}
+}
+
+func TestSizedBufferPoolTemplate(t *testing.T) {
+	template := []byte("HDR:")
+
+	bufPool := NewSizedBufferPoolWithOptions(WithSize(2), WithAlloc(64),
+		WithTemplate(template))
+
+	for i := 0; i < 4; i++ {
+		b := bufPool.Get()
+		if !bytes.Equal(b.Bytes(), template) {
+			t.Fatalf("buffer doesn't start with template: got %q want %q",
+				b.Bytes(), template)
+		}
+		b.WriteString("payload")
+		bufPool.Put(b)
+	}
+}
+
+func TestSizedBufferPoolShrinkFactor(t *testing.T) {
+	var size int = 8
+
+	bufPool := NewSizedBufferPoolWithOptions(WithSize(size), WithAlloc(64),
+		WithShrinkFactor(0.5))
+	bufPool.Prefill()
+
+	// Demand drops: buffers keep coming back to a full pool.
+	for i := 0; i < 1000 && bufPool.Len() == size; i++ {
+		bufPool.Put(bytes.NewBuffer(make([]byte, 0, 64)))
+	}
+	if bufPool.Len() >= size {
+		t.Fatalf("full pool didn't shrink: got %v idle buffers", bufPool.Len())
+	}
+
+	// Oversized and aliased buffers returned to a full pool count as well.
+	for _, put := range []func(bp *SizedBufferPool){
+		func(bp *SizedBufferPool) { bp.Put(bytes.NewBuffer(make([]byte, 0, 1024))) },
+		func(bp *SizedBufferPool) { bp.SafePut(bytes.NewBuffer(make([]byte, 0, 64)), true) },
+	} {
+		bufPool = NewSizedBufferPoolWithOptions(WithSize(size), WithAlloc(64),
+			WithMaxCap(128), WithShrinkFactor(1))
+		bufPool.Prefill()
+		put(bufPool)
+		if bufPool.Len() != size-1 {
+			t.Fatalf("full pool didn't shrink on replaced buffer: got %v want %v",
+				bufPool.Len(), size-1)
+		}
+	}
+
+	// Without a shrink factor the pool stays full.
+	bufPool = NewSizedBufferPool(size, 64)
+	bufPool.Prefill()
+	for i := 0; i < 100; i++ {
+		bufPool.Put(bytes.NewBuffer(make([]byte, 0, 64)))
+	}
+	if bufPool.Len() != size {
+		t.Fatalf("pool shrank by default: got %v want %v", bufPool.Len(), size)
+	}
+}
+
+// TestSizedBufferPoolPutReset checks the state of retained, discarded and
+// replacement buffers when Put both zeroes and restores a template.
+func TestSizedBufferPoolPutReset(t *testing.T) {
+	template := []byte("HDR:")
+
+	bufPool := NewSizedBufferPoolWithOptions(WithSize(1), WithAlloc(64),
+		WithMaxCap(128), WithTemplate(template), WithZeroOnPut(true))
+
+	// A retained buffer is zeroed and holds the template once.
+	b := bufPool.Get()
+	b.WriteString("secret")
+	bufPool.Put(b)
+	if !bytes.Equal(b.Bytes(), template) {
+		t.Fatalf("retained buffer invalid: got %q want %q", b.Bytes(), template)
+	}
+	for i, c := range b.Bytes()[len(template):b.Cap()] {
+		if c != 0 {
+			t.Fatalf("retained byte %v not zeroed: got %#x", i, c)
+		}
+	}
+
+	// A discarded buffer is zeroed.
+	d := bytes.NewBufferString("secret")
+	bufPool.Put(d)
+	if d.Len() != 0 || bytes.Contains(d.Bytes()[:d.Cap()], []byte("secret")) {
+		t.Fatalf("discarded buffer not zeroed")
+	}
+
+	// An oversized buffer is zeroed and replaced by a fresh buffer holding the
+	// template once.
+	bufPool.Get()
+	o := bytes.NewBuffer(make([]byte, 0, 256))
+	o.WriteString("secret")
+	bufPool.Put(o)
+	if bytes.Contains(o.Bytes()[:o.Cap()], []byte("secret")) {
+		t.Fatalf("oversized buffer not zeroed")
+	}
+	if r := bufPool.Get(); r == o || !bytes.Equal(r.Bytes(), template) {
+		t.Fatalf("replacement buffer invalid: got %q want %q", r.Bytes(), template)
+	}
+}
+
+func TestSizedBufferPoolAllocFunc(t *testing.T) {
+	var calls int
+	arena := make([]byte, 0, 1<<10)
+
+	bufPool := NewSizedBufferPoolWithOptions(WithSize(1), WithAlloc(64),
+		WithMaxCap(128),
+		WithAllocFunc(func(capacity int) *bytes.Buffer {
+			calls++
+			n := len(arena)
+			arena = arena[:n+capacity]
+			return bytes.NewBuffer(arena[n:n:len(arena)])
+		}))
+
+	bufPool.Get()                                       // miss
+	bufPool.GetWithHint(100)                            // miss
+	bufPool.Put(bytes.NewBuffer(make([]byte, 0, 1024))) // oversized replacement
+	bufPool.Drain()
+	bufPool.Prefill()
+
+	if calls != 4 {
+		t.Fatalf("alloc func not used for every new buffer: got %v calls want %v",
+			calls, 4)
+	}
+	if len(arena) != 64+100+64+64 {
+		t.Fatalf("buffers not allocated from the arena: got %v bytes used",
+			len(arena))
+	}
+}
+
+func TestSizedBufferPoolMaxLive(t *testing.T) {
+	for _, tc := range []struct{ size, maxLive int }{
+		{4, 2}, // maxLive smaller than size
+		{1, 3}, // maxLive larger than size
+	} {
+		bufPool := NewSizedBufferPoolWithOptions(WithSize(tc.size), WithAlloc(64),
+			WithMaxLive(tc.maxLive))
+
+		var bufs []*bytes.Buffer
+		for i := 0; i < tc.maxLive; i++ {
+			bufs = append(bufs, bufPool.Get())
+		}
+
+		if _, ok := bufPool.TryGet(); ok {
+			t.Fatalf("%+v: TryGet beyond maxLive succeeded", tc)
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		if _, err := bufPool.GetContext(ctx); !errors.Is(err, ErrPoolExhausted) ||
+			!errors.Is(err, context.DeadlineExceeded) {
+			t.Fatalf("%+v: GetContext beyond maxLive: got %v want %v", tc, err,
+				context.DeadlineExceeded)
+		}
+		cancel()
+
+		// Get blocks until a buffer is returned.
+		done := make(chan *bytes.Buffer)
+		go func() {
+			done <- bufPool.Get()
+		}()
+		select {
+		case <-done:
+			t.Fatalf("%+v: Get beyond maxLive didn't block", tc)
+		case <-time.After(10 * time.Millisecond):
+		}
+		bufPool.Put(bufs[0])
+		if got := <-done; got != bufs[0] {
+			t.Fatalf("%+v: blocked Get didn't receive the returned buffer", tc)
+		}
+	}
+}
+
+func TestSizedBufferPoolZeroOnGet(t *testing.T) {
+	bufPool := NewSizedBufferPoolWithOptions(WithSize(1), WithAlloc(64),
+		WithZeroOnGet(true))
+
+	// Return a buffer holding sentinel bytes without zeroing it.
+	b := bytes.NewBuffer(make([]byte, 0, 64))
+	b.Write(bytes.Repeat([]byte{0xAA}, 64))
+	bufPool.Put(b)
+
+	got := bufPool.Get()
+	if got != b {
+		t.Fatalf("buffer not reused")
+	}
+	for i, c := range got.Bytes()[:got.Cap()] {
+		if c != 0 {
+			t.Fatalf("byte %v not zeroed: got %#x", i, c)
+		}
+	}
+}
+
+func TestSizedBufferPoolDebugPoison(t *testing.T) {
+	bufPool := NewSizedBufferPoolWithOptions(WithSize(1), WithAlloc(64),
+		WithDebugPoison(true))
+
+	b := bufPool.Get()
+	b.WriteString("hello")
+	stale := b.Bytes()
+	bufPool.Put(b)
+
+	// Reads after Put, through retained slices or the buffer itself, see the
+	// poison pattern.
+	if !bytes.Equal(stale, bytes.Repeat([]byte{0xDE}, len(stale))) {
+		t.Fatalf("stale slice not poisoned: got %q", stale)
+	}
+	for i, c := range b.Bytes()[:b.Cap()] {
+		if c != 0xDE {
+			t.Fatalf("byte %v not poisoned: got %#x", i, c)
+		}
+	}
+
+	// The buffer is handed out empty again.
+	if got := bufPool.Get(); got != b || got.Len() != 0 {
+		t.Fatalf("poisoned buffer not reused empty: got len %v", got.Len())
+	}
+
+	// Discarded buffers are poisoned too.
+	d := bytes.NewBufferString("secret")
+	bufPool.Put(bufPool.Get())
+	bufPool.Put(d)
+	if bytes.Contains(d.Bytes()[:d.Cap()], []byte("secret")) {
+		t.Fatalf("discarded buffer not poisoned")
+	}
+}
+
+func TestSizedBufferPoolRetainTolerance(t *testing.T) {
+	bufPool := NewSizedBufferPoolWithOptions(WithSize(2), WithAlloc(100),
+		WithRetainTolerance(2))
+
+	// A buffer at 1.5x the capacity is retained as is.
+	b := bytes.NewBuffer(make([]byte, 0, 150))
+	bufPool.Put(b)
+	if got := bufPool.Get(); got != b {
+		t.Fatalf("buffer within tolerance replaced")
+	}
+
+	// One beyond 2x is replaced by a buffer of the pool's capacity.
+	bufPool.Put(bytes.NewBuffer(make([]byte, 0, 201)))
+	if got := bufPool.Get(); got.Cap() != 100 {
+		t.Fatalf("buffer beyond tolerance retained: got cap %v want %v", got.Cap(), 100)
+	}
+	if n := bufPool.Stats().OversizeDiscards; n != 1 {
+		t.Fatalf("oversize discards invalid: got %v want %v", n, 1)
+	}
+}
+
+func TestSizedBufferPoolMinRetain(t *testing.T) {
+	for _, tc := range []struct {
+		minRetain time.Duration
+		discards  uint64
+	}{
+		{0, 1},
+		{time.Second, 0},
+	} {
+		bufPool := NewSizedBufferPoolWithOptions(WithSize(1), WithAlloc(64),
+			WithMinRetain(tc.minRetain))
+		bufPool.Put(bufPool.Get())
+
+		// The pool is full until the idle buffer is taken shortly after.
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			time.Sleep(10 * time.Millisecond)
+			bufPool.Get()
+		}()
+		bufPool.Put(bytes.NewBuffer(make([]byte, 0, 64)))
+		<-done
+
+		if got := bufPool.Stats().Discards; got != tc.discards {
+			t.Fatalf("min retain %v: discards invalid: got %v want %v",
+				tc.minRetain, got, tc.discards)
+		}
+	}
+}
+
+func TestSizedBufferPoolAbsoluteMaxCap(t *testing.T) {
+	bufPool := NewSizedBufferPoolWithOptions(WithSize(2), WithAlloc(64),
+		WithAbsoluteMaxCap(128))
+
+	// Put retains the buffer, as there is no limit on Put.
+	big := bytes.NewBuffer(make([]byte, 0, 1024))
+	bufPool.Put(big)
+	if bufPool.Len() != 1 {
+		t.Fatalf("oversized buffer not retained: got %v idle want %v", bufPool.Len(), 1)
+	}
+
+	if got := bufPool.Get(); got == big || got.Cap() != 64 {
+		t.Fatalf("Get handed out oversized buffer: got cap %v want %v", got.Cap(), 64)
+	}
+	if got := bufPool.Stats(); got.OversizeDiscards != 1 || got.Misses != 1 {
+		t.Fatalf("replacement stats invalid: got %+v", got)
+	}
+}
+
+func TestSizedBufferPoolPutSpin(t *testing.T) {
+	const rounds = 100
+	discards := func(spin int) uint64 {
+		bufPool := NewSizedBufferPoolWithOptions(WithSize(1), WithAlloc(1<<20),
+			WithPutSpin(spin))
+		for i := 0; i < rounds; i++ {
+			bufPool.Put(bufPool.Get())
+
+			// The getter frees the slot right as the large buffer is put.
+			ready, done := make(chan struct{}), make(chan struct{})
+			go func() {
+				defer close(done)
+				<-ready
+				bufPool.Get()
+			}()
+			close(ready)
+			bufPool.Put(bytes.NewBuffer(make([]byte, 0, 1<<20)))
+			<-done
+			bufPool.Get()
+		}
+		return bufPool.Stats().Discards
+	}
+
+	without := discards(0)
+	if with := discards(100000); with != 0 {
+		t.Fatalf("large buffers discarded with spin: got %v want %v (%v without)",
+			with, 0, without)
+	}
+	t.Logf("discards without spin: %v of %v", without, rounds)
+}
+
+func TestSizedBufferPoolHeadroom(t *testing.T) {
+	bufPool := NewSizedBufferPoolWithOptions(WithAlloc(1000), WithHeadroom(1.25))
+	if c := bufPool.Get().Cap(); c != 1250 {
+		t.Fatalf("cap with headroom invalid: got %v want %v", c, 1250)
+	}
+
+	bufPool = NewSizedBufferPoolWithOptions(WithAlloc(1000), WithHeadroom(1.25),
+		WithMaxCap(1100))
+	if c := bufPool.Get().Cap(); c != 1100 {
+		t.Fatalf("cap with headroom over max cap invalid: got %v want %v", c, 1100)
+	}
+
+	bufPool = NewSizedBufferPoolWithOptions(WithAlloc(1000), WithHeadroom(1.25),
+		WithRetainTolerance(1.1))
+	b := bufPool.Get()
+	if c := b.Cap(); c != 1100 {
+		t.Fatalf("cap with headroom over retain tolerance invalid: got %v want %v", c, 1100)
+	}
+	bufPool.Put(b)
+	if s := bufPool.Stats(); s.Discards != 0 || s.PutReplaceAllocs != 0 {
+		t.Fatalf("buffer with headroom not retained: got %+v", s)
+	}
+}
+
+func TestSizedBufferPoolMaxLiveVariants(t *testing.T) {
+	for name, get := range map[string]func(bp *SizedBufferPool) *bytes.Buffer{
+		"GetExact": func(bp *SizedBufferPool) *bytes.Buffer { return bp.GetExact(100) },
+		"GetWait":  func(bp *SizedBufferPool) *bytes.Buffer { return bp.GetWait(time.Millisecond) },
+	} {
+		bufPool := NewSizedBufferPoolWithOptions(WithSize(4), WithAlloc(64),
+			WithMaxLive(1))
+		held := bufPool.Get()
+
+		got := make(chan *bytes.Buffer)
+		go func() {
+			got <- get(bufPool)
+		}()
+		select {
+		case <-got:
+			t.Fatalf("%v beyond maxLive didn't block", name)
+		case <-time.After(20 * time.Millisecond):
+		}
+		bufPool.Put(held)
+		select {
+		case b := <-got:
+			if b != held {
+				t.Fatalf("%v didn't reuse the returned buffer", name)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("%v blocked after a buffer was returned", name)
+		}
+	}
+}
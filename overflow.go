@@ -0,0 +1,44 @@
+package bpool
+
+import (
+	"bytes"
+)
+
+// spill tries to keep an oversized buffer in the overflow pool instead of
+// discarding it, and reports whether it did.
+func (bp *SizedBufferPool) spill(b *bytes.Buffer) bool {
+	if bp.opt.overflowCap > 0 && b.Cap() > bp.opt.overflowCap {
+		return false
+	}
+	bp.reset(b)
+	select {
+	case bp.over <- b:
+		return true
+	default:
+		return false
+	}
+}
+
+// unspill takes a buffer from the overflow pool, if there is one, for a Get
+// that asked for at least minCap bytes. Requests that fit the pool's capacity
+// are left to the regular pool.
+func (bp *SizedBufferPool) unspill(minCap int) (*bytes.Buffer, bool) {
+	if bp.over == nil || minCap <= bp.Cap() {
+		return nil, false
+	}
+	select {
+	case b := <-bp.over:
+		if bp.opt.zeroOnGet {
+			bp.scrub(b)
+		}
+		return bp.lend(b), true
+	default:
+		return nil, false
+	}
+}
+
+// OverflowLen returns the number of buffers currently held by the overflow
+// pool set up with WithOverflowPool.
+func (bp *SizedBufferPool) OverflowLen() int {
+	return len(bp.over)
+}
@@ -0,0 +1,68 @@
+package bpool
+
+import (
+	"testing"
+)
+
+func TestSizedBufferPoolOverflow(t *testing.T) {
+	bufPool := NewSizedBufferPoolWithOptions(WithSize(2), WithAlloc(64),
+		WithMaxCap(128), WithOverflowPool(1, 4096))
+
+	big := bufPool.Get()
+	big.Write(make([]byte, 1024))
+	bufCap := big.Cap()
+	bufPool.Put(big)
+
+	if bufPool.OverflowLen() != 1 || bufPool.Len() != 0 {
+		t.Fatalf("oversized buffer not kept in overflow: got overflow %v idle %v",
+			bufPool.OverflowLen(), bufPool.Len())
+	}
+	if got := bufPool.Stats().Discards; got != 0 {
+		t.Fatalf("oversized buffer discarded: got %v discards want 0", got)
+	}
+
+	// Small requests leave the overflow pool alone.
+	small := bufPool.Get()
+	if small == big || bufPool.OverflowLen() != 1 {
+		t.Fatalf("small Get served from overflow")
+	}
+	bufPool.Put(small)
+
+	b := bufPool.GetGrow(512)
+	if b != big {
+		t.Fatalf("large GetGrow not served from overflow")
+	}
+	if b.Len() != 0 || b.Cap() != bufCap {
+		t.Fatalf("overflow buffer invalid: got len %v cap %v want len 0 cap %v",
+			b.Len(), b.Cap(), bufCap)
+	}
+	if misses := bufPool.Stats().Misses; misses != 2 {
+		t.Fatalf("misses invalid: got %v want %v", misses, 2)
+	}
+	bufPool.Put(b)
+
+	// The overflow pool is full, so a second oversized buffer is discarded.
+	other := bufPool.Get()
+	other.Write(make([]byte, 1024))
+	bufPool.Put(other)
+	if bufPool.OverflowLen() != 1 || bufPool.Stats().OversizeDiscards != 1 {
+		t.Fatalf("overflow pool exceeded its size: got %v oversize discards %v",
+			bufPool.OverflowLen(), bufPool.Stats().OversizeDiscards)
+	}
+
+	// Buffers beyond the overflow capacity are discarded too.
+	huge := bufPool.GetGrow(512)
+	huge.Write(make([]byte, 8192))
+	bufPool.Put(huge)
+	if bufPool.OverflowLen() != 0 {
+		t.Fatalf("buffer over overflow capacity retained")
+	}
+
+	// PutShrunk never retains oversized buffers.
+	shrunk := bufPool.Get()
+	shrunk.Write(make([]byte, 1024))
+	bufPool.PutShrunk(shrunk)
+	if bufPool.OverflowLen() != 0 {
+		t.Fatalf("PutShrunk used the overflow pool")
+	}
+}
@@ -0,0 +1,31 @@
+package bpool
+
+import (
+	"bytes"
+)
+
+// lend records that b is handed out by the pool, in strict ownership mode,
+// and watches it for leaks if leak detection is enabled.
+func (bp *SizedBufferPool) lend(b *bytes.Buffer) *bytes.Buffer {
+	if bp.opt.leakf != nil {
+		bp.watchLeak(b)
+	}
+	if bp.owned != nil {
+		bp.ownedMu.Lock()
+		bp.owned[b] = struct{}{}
+		bp.ownedMu.Unlock()
+	}
+	return b
+}
+
+// reclaim records that b was returned to the pool, and panics if b isn't
+// currently handed out by it.
+func (bp *SizedBufferPool) reclaim(b *bytes.Buffer) {
+	bp.ownedMu.Lock()
+	_, ok := bp.owned[b]
+	delete(bp.owned, b)
+	bp.ownedMu.Unlock()
+	if !ok {
+		panic("bpool: Put of a buffer that is not owned by the pool or was already returned")
+	}
+}
@@ -0,0 +1,33 @@
+package bpool
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestSizedBufferPoolStrictOwnership(t *testing.T) {
+	bufPool := NewSizedBufferPoolWithOptions(WithSize(4), WithAlloc(64),
+		WithStrictOwnership(true))
+
+	mustPanic := func(name string, fn func()) {
+		defer func() {
+			if recover() == nil {
+				t.Fatalf("%v: Put didn't panic", name)
+			}
+		}()
+		fn()
+	}
+
+	// Buffers from the pool can be returned once, whether new or reused.
+	b := bufPool.Get()
+	bufPool.Put(b)
+	b = bufPool.Get()
+	bufPool.Put(b)
+
+	mustPanic("double Put", func() {
+		bufPool.Put(b)
+	})
+	mustPanic("foreign buffer", func() {
+		bufPool.Put(new(bytes.Buffer))
+	})
+}
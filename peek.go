@@ -0,0 +1,75 @@
+package bpool
+
+import (
+	"bytes"
+)
+
+// PeekCap returns the capacity of the buffer the next Get would return, and
+// whether the pool holds an idle buffer at all, without taking it.
+//
+// Since a channel can't be peeked into, a pool not created with WithLIFO
+// moves its oldest idle buffer aside into a single slot that the next Get
+// takes first. A GetWait blocked on an empty pool isn't woken up by a buffer
+// moved aside; it takes the buffer on the next Put.
+func (bp *SizedBufferPool) PeekCap() (int, bool) {
+	if bp.lifo != nil {
+		s := bp.lifo
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		if n := len(s.bufs); n > 0 {
+			return s.bufs[n-1].Cap(), true
+		}
+		return 0, false
+	}
+	bp.peekMu.Lock()
+	defer bp.peekMu.Unlock()
+	if b := bp.peeked.Load(); b != nil {
+		return b.Cap(), true
+	}
+	c := bp.ch()
+	select {
+	case b := <-c:
+		bp.peeked.Store(b)
+		bp.fitPeeked(c)
+		return b.Cap(), true
+	default:
+		return 0, false
+	}
+}
+
+// fitPeeked evicts an idle buffer from c, or the buffer moved aside if c is
+// empty, if a Put racing with PeekCap or a Resize left the pool holding more
+// than its size. peekMu must be held.
+func (bp *SizedBufferPool) fitPeeked(c chan *bytes.Buffer) {
+	if bp.peeked.Load() == nil || len(c) < cap(c) {
+		return
+	}
+	select {
+	case b := <-c:
+		bp.evict(b)
+	default:
+		if b := bp.peeked.Swap(nil); b != nil {
+			bp.evict(b)
+		}
+	}
+}
+
+// visitPeeked calls fn with the buffer moved aside by PeekCap, if there is
+// one, and keeps the buffer aside only if fn returns true. The buffer is out
+// of reach of Get while fn runs.
+func (bp *SizedBufferPool) visitPeeked(fn func(b *bytes.Buffer) bool) {
+	bp.peekMu.Lock()
+	defer bp.peekMu.Unlock()
+	if b := bp.peeked.Swap(nil); b != nil && fn(b) {
+		bp.peeked.Store(b)
+	}
+}
+
+// unpeek takes the buffer moved aside by PeekCap, if there is one.
+func (bp *SizedBufferPool) unpeek() (b *bytes.Buffer, ok bool) {
+	if bp.peeked.Load() == nil {
+		return nil, false
+	}
+	b = bp.peeked.Swap(nil)
+	return b, b != nil
+}
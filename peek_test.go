@@ -0,0 +1,136 @@
+package bpool
+
+import (
+	"bytes"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestSizedBufferPoolPeekCap(t *testing.T) {
+	for _, lifo := range []bool{false, true} {
+		bufPool := NewSizedBufferPoolWithOptions(WithSize(4), WithAlloc(8),
+			WithMaxCap(1024), WithLIFO(lifo))
+		if c, ok := bufPool.PeekCap(); ok {
+			t.Fatalf("lifo %v: peek of empty pool invalid: got %v want none", lifo, c)
+		}
+
+		first, second := bytes.NewBuffer(make([]byte, 0, 16)), bytes.NewBuffer(make([]byte, 0, 32))
+		bufPool.Put(first)
+		bufPool.Put(second)
+		next := first
+		if lifo {
+			next = second
+		}
+
+		for i := 0; i < 2; i++ {
+			c, ok := bufPool.PeekCap()
+			if !ok || c != next.Cap() {
+				t.Fatalf("lifo %v: peek invalid: got %v, %v want %v, true", lifo, c, ok, next.Cap())
+			}
+			if n := bufPool.Len(); n != 2 {
+				t.Fatalf("lifo %v: len after peek invalid: got %v want %v", lifo, n, 2)
+			}
+		}
+		if b := bufPool.Get(); b != next {
+			t.Fatalf("lifo %v: get after peek invalid: got cap %v want %v", lifo, b.Cap(), next.Cap())
+		}
+		if n := bufPool.Len(); n != 1 {
+			t.Fatalf("lifo %v: len after get invalid: got %v want %v", lifo, n, 1)
+		}
+	}
+}
+
+func TestSizedBufferPoolPeekCapIdle(t *testing.T) {
+	var ttl time.Duration = time.Minute
+	bufPool := NewSizedBufferPoolWithOptions(WithSize(4), WithAlloc(8),
+		WithMaxCap(1024), WithIdleTTL(ttl))
+	defer bufPool.Stop()
+
+	now := time.Unix(0, 0)
+	bufPool.now = func() time.Time {
+		return now
+	}
+
+	bufPool.Put(bytes.NewBuffer(make([]byte, 0, 16)))
+	bufPool.Put(bytes.NewBuffer(make([]byte, 0, 32)))
+	bufPool.PeekCap()
+	if n := bufPool.CapBytes(); n != 48 {
+		t.Fatalf("idle capacity after peek invalid: got %v want %v", n, 48)
+	}
+	if c, ok := bufPool.PeekCap(); !ok || c != 16 {
+		t.Fatalf("peek after visit invalid: got %v, %v want %v, true", c, ok, 16)
+	}
+
+	// The buffer moved aside expires like the others.
+	now = now.Add(ttl + time.Second)
+	bufPool.evictIdle()
+	if n := bufPool.Len(); n != 0 {
+		t.Fatalf("len after eviction invalid: got %v want %v", n, 0)
+	}
+	if c, ok := bufPool.PeekCap(); ok {
+		t.Fatalf("peek after eviction invalid: got %v want none", c)
+	}
+}
+
+func TestSizedBufferPoolPeekCapConcurrentPut(t *testing.T) {
+	size := 4
+	bufPool := NewSizedBufferPoolWithOptions(WithSize(size), WithAlloc(8))
+
+	for i := 0; i < 20; i++ {
+		bufPool.Drain()
+		for j := 0; j < size; j++ {
+			bufPool.give(bytes.NewBuffer(make([]byte, 0, 8)))
+		}
+
+		// Puts racing with PeekCap moving a buffer aside from a full pool
+		// must not leave it holding more than its size.
+		var stop atomic.Bool
+		var wg, started sync.WaitGroup
+		for j := 0; j < 2; j++ {
+			wg.Add(1)
+			started.Add(1)
+			go func() {
+				defer wg.Done()
+				started.Done()
+				for !stop.Load() {
+					bufPool.give(bytes.NewBuffer(make([]byte, 0, 8)))
+				}
+			}()
+		}
+		started.Wait()
+		bufPool.PeekCap()
+		stop.Store(true)
+		wg.Wait()
+		if n := bufPool.Len(); n > size {
+			t.Fatalf("len after concurrent Puts invalid: got %v want at most %v", n, size)
+		}
+	}
+}
+
+func TestSizedBufferPoolPeekCapResize(t *testing.T) {
+	for _, size := range []int{0, 1, 2} {
+		bufPool := NewSizedBufferPoolWithOptions(WithSize(4), WithAlloc(8))
+		for i := 0; i < 3; i++ {
+			bufPool.Put(bytes.NewBuffer(make([]byte, 0, 8)))
+		}
+		bufPool.PeekCap()
+		bufPool.Resize(size)
+		if n := bufPool.Len(); n != size {
+			t.Fatalf("size %v: len after resize invalid: got %v want %v", size, n, size)
+		}
+	}
+
+	bufPool := NewSizedBufferPoolWithOptions(WithSize(4), WithAlloc(8))
+	bufPool.Put(bytes.NewBuffer(make([]byte, 0, 8)))
+	bufPool.Put(bytes.NewBuffer(make([]byte, 0, 8)))
+	bufPool.PeekCap()
+	bufPool.Rotate()
+	if n := bufPool.Len(); n != 0 {
+		t.Fatalf("len after rotate invalid: got %v want %v", n, 0)
+	}
+	if c, ok := bufPool.PeekCap(); ok {
+		t.Fatalf("peek after rotate invalid: got %v want none", c)
+	}
+}
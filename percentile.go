@@ -0,0 +1,63 @@
+package bpool
+
+import (
+	"slices"
+	"sync"
+)
+
+// capWindow holds the capacities of the most recently returned buffers for
+// WithP95Sizing.
+type capWindow struct {
+	mu   sync.Mutex
+	caps []int
+	next int
+}
+
+// recordP95 adds the capacity of a returned buffer to the window. Each time
+// the window has been filled, the pool's capacity is set to the 95th
+// percentile of the capacities it holds, but never below the capacity set
+// with WithAlloc nor above the largest capacity Put retains. Nothing is
+// recorded while the pool is frozen.
+func (bp *SizedBufferPool) recordP95(capacity int) {
+	if bp.frozen.Load() {
+		return
+	}
+	w := bp.caps
+	w.mu.Lock()
+	w.caps[w.next] = capacity
+	w.next++
+	if w.next < len(w.caps) {
+		w.mu.Unlock()
+		return
+	}
+	w.next = 0
+	p := max(percentile(w.caps, 95), bp.opt.alloc)
+	w.mu.Unlock()
+	if m := bp.maxCap(); m > 0 {
+		p = min(p, m)
+	}
+	bp.SetCap(p)
+}
+
+// percentile returns the p-th percentile of lens using the nearest-rank
+// method. lens must not be empty.
+func percentile(lens []int, p int) int {
+	sorted := slices.Clone(lens)
+	slices.Sort(sorted)
+	rank := (len(sorted)*p + 99) / 100
+	return sorted[max(rank, 1)-1]
+}
+
+// Freeze stops Put from adapting the pool's capacity, e.g. once a warm-up
+// period has settled on a good size. Buffers are still retained and
+// discarded based on the frozen capacity, and SetCap still changes it.
+// Without WithP95Sizing the capacity never adapts and Freeze has no effect.
+func (bp *SizedBufferPool) Freeze() {
+	bp.frozen.Store(true)
+}
+
+// Unfreeze lets Put adapt the pool's capacity again after Freeze. Buffers
+// returned while the pool was frozen are not taken into account.
+func (bp *SizedBufferPool) Unfreeze() {
+	bp.frozen.Store(false)
+}
@@ -0,0 +1,163 @@
+package bpool
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestSizedBufferPoolP95Sizing(t *testing.T) {
+	const window = 100
+	bufPool := NewSizedBufferPoolWithOptions(WithSize(4), WithAlloc(64),
+		WithP95Sizing(window))
+
+	// A heavy-tailed workload: 94% small, 5% medium and 1% huge writes.
+	lengths := make([]int, 0, window)
+	sum := 0
+	for i := 0; i < window; i++ {
+		n := 100
+		switch {
+		case i%100 == 50:
+			n = 100000
+		case i%20 == 5:
+			n = 1000
+		}
+		lengths = append(lengths, n)
+		sum += n
+	}
+
+	for _, n := range lengths[:window-1] {
+		bufPool.Put(bytes.NewBuffer(make([]byte, n)))
+	}
+	if bufPool.Cap() != 64 {
+		t.Fatalf("capacity changed before the window was full: got %v", bufPool.Cap())
+	}
+	bufPool.Put(bytes.NewBuffer(make([]byte, lengths[window-1])))
+
+	// The mean, which an average-based estimate converges to, is dragged up
+	// by the single outlier; the percentile is not.
+	if got := bufPool.Cap(); got != 1000 {
+		t.Fatalf("P95 capacity invalid: got %v want %v (mean %v)", got, 1000,
+			sum/window)
+	}
+	bufPool.Drain()
+	if b := bufPool.Get(); b.Cap() != 1000 {
+		t.Fatalf("new buffer not sized to P95: got %v", b.Cap())
+	}
+}
+
+// TestSizedBufferPoolP95SizingPutLen checks that buffers returned with PutLen
+// are sized by the bytes written to them rather than their capacity.
+func TestSizedBufferPoolP95SizingPutLen(t *testing.T) {
+	const window = 20
+	bufPool := NewSizedBufferPoolWithOptions(WithSize(4), WithAlloc(64),
+		WithP95Sizing(window))
+
+	for i := 0; i < window; i++ {
+		b := bytes.NewBuffer(make([]byte, 0, 4096))
+		b.Write(make([]byte, 300))
+		bufPool.PutLen(b)
+	}
+	if got := bufPool.Cap(); got != 300 {
+		t.Fatalf("capacity does not track length: got %v want %v", got, 300)
+	}
+
+	for i := 0; i < window; i++ {
+		b := bytes.NewBuffer(make([]byte, 0, 4096))
+		b.Write(make([]byte, 300))
+		bufPool.Put(b)
+	}
+	if got := bufPool.Cap(); got != 4096 {
+		t.Fatalf("Put capacity invalid: got %v want %v", got, 4096)
+	}
+}
+
+// TestSizedBufferPoolP95SizingZeroCap checks that a single Put of a buffer
+// that was never written to can't collapse the capacity of the pool.
+func TestSizedBufferPoolP95SizingZeroCap(t *testing.T) {
+	const window = 20
+	bufPool := NewSizedBufferPoolWithOptions(WithSize(4), WithAlloc(64),
+		WithP95Sizing(window))
+
+	for i := 0; i < window-1; i++ {
+		bufPool.Put(bytes.NewBuffer(make([]byte, 100)))
+	}
+	bufPool.Put(new(bytes.Buffer))
+	if bufPool.Cap() != 100 {
+		t.Fatalf("capacity after zero-cap put invalid: got %v want %v", bufPool.Cap(), 100)
+	}
+	for i := 0; i < 4; i++ {
+		if c := bufPool.Get().Cap(); c < 100 {
+			t.Fatalf("cap of buffer after zero-cap put invalid: got %v want at least %v", c, 100)
+		}
+	}
+}
+
+func TestSizedBufferPoolP95SizingBounds(t *testing.T) {
+	const window = 10
+	bufPool := NewSizedBufferPoolWithOptions(WithSize(4), WithAlloc(64),
+		WithMaxCap(1000), WithP95Sizing(window))
+
+	// Buffers emptied by their readers are sized by capacity, not length.
+	for i := 0; i < window; i++ {
+		b := bytes.NewBuffer(make([]byte, 500))
+		b.Next(500)
+		bufPool.Put(b)
+	}
+	if bufPool.Cap() != 500 {
+		t.Fatalf("capacity from emptied buffers invalid: got %v want %v", bufPool.Cap(), 500)
+	}
+
+	for i := 0; i < window; i++ {
+		bufPool.Put(bytes.NewBuffer(make([]byte, 0, 16)))
+	}
+	if bufPool.Cap() != 64 {
+		t.Fatalf("capacity below alloc invalid: got %v want %v", bufPool.Cap(), 64)
+	}
+
+	for i := 0; i < window; i++ {
+		bufPool.Put(bytes.NewBuffer(make([]byte, 0, 5000)))
+	}
+	if bufPool.Cap() != 1000 {
+		t.Fatalf("capacity above max cap invalid: got %v want %v", bufPool.Cap(), 1000)
+	}
+}
+
+func TestPercentile(t *testing.T) {
+	for _, tc := range []struct {
+		lens []int
+		p    int
+		want int
+	}{
+		{[]int{7}, 95, 7},
+		{[]int{5, 1, 4, 2, 3}, 50, 3},
+		{[]int{5, 1, 4, 2, 3}, 95, 5},
+		{[]int{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}, 90, 9},
+	} {
+		if got := percentile(tc.lens, tc.p); got != tc.want {
+			t.Fatalf("percentile(%v, %v) invalid: got %v want %v", tc.lens, tc.p,
+				got, tc.want)
+		}
+	}
+}
+
+func TestSizedBufferPoolFreeze(t *testing.T) {
+	const window = 10
+	bufPool := NewSizedBufferPoolWithOptions(WithSize(4), WithAlloc(64),
+		WithP95Sizing(window))
+
+	bufPool.Freeze()
+	for i := 0; i < 10*window; i++ {
+		bufPool.Put(bytes.NewBuffer(make([]byte, (i%7+1)*1000)))
+	}
+	if bufPool.Cap() != 64 {
+		t.Fatalf("frozen capacity changed: got %v want %v", bufPool.Cap(), 64)
+	}
+
+	bufPool.Unfreeze()
+	for i := 0; i < window; i++ {
+		bufPool.Put(bytes.NewBuffer(make([]byte, 500)))
+	}
+	if bufPool.Cap() != 500 {
+		t.Fatalf("unfrozen capacity invalid: got %v want %v", bufPool.Cap(), 500)
+	}
+}
@@ -0,0 +1,57 @@
+package bpool
+
+import (
+	"bytes"
+	"runtime"
+)
+
+// PerPBufferPool keeps one SizedBufferPool per processor (P) of the Go
+// scheduler, and serves each Get and Put from the shard of the P the calling
+// goroutine runs on. Since a goroutine rarely migrates between Ps, it almost
+// always touches the same shard, which minimizes cross-core traffic. Unlike
+// ShardedBufferPool, other shards are never tried, so a Get may allocate
+// while another shard holds idle buffers.
+//
+// Built with the purego tag, the shard is chosen at random instead of by P.
+type PerPBufferPool struct {
+	shards []*SizedBufferPool
+}
+
+// NewPerPBufferPool creates a new PerPBufferPool with one shard per
+// GOMAXPROCS, each retaining up to sizePerP buffers with an initial capacity
+// of alloc. Shards are not added if GOMAXPROCS is raised later; Ps beyond
+// the initial count share the existing shards.
+func NewPerPBufferPool(sizePerP int, alloc int) (bp *PerPBufferPool) {
+	bp = &PerPBufferPool{
+		shards: make([]*SizedBufferPool, runtime.GOMAXPROCS(0)),
+	}
+	for i := range bp.shards {
+		bp.shards[i] = NewSizedBufferPool(sizePerP, alloc)
+	}
+	return
+}
+
+// shard returns the shard of the P the calling goroutine runs on.
+func (bp *PerPBufferPool) shard() *SizedBufferPool {
+	return bp.shards[procID()%len(bp.shards)]
+}
+
+// Get gets a Buffer from the local shard, or creates a new one if it is
+// empty.
+func (bp *PerPBufferPool) Get() *bytes.Buffer {
+	return bp.shard().Get()
+}
+
+// Put returns the given Buffer to the local shard. The buffer is discarded if
+// the shard is full.
+func (bp *PerPBufferPool) Put(b *bytes.Buffer) {
+	bp.shard().Put(b)
+}
+
+// Len returns the number of idle buffers currently held by all shards.
+func (bp *PerPBufferPool) Len() (n int) {
+	for _, shard := range bp.shards {
+		n += shard.Len()
+	}
+	return
+}
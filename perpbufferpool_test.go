@@ -0,0 +1,37 @@
+package bpool
+
+import (
+	"bytes"
+	"fmt"
+	"runtime"
+	"testing"
+)
+
+func TestPerPBufferPool(t *testing.T) {
+	bufPool := NewPerPBufferPool(2, 64)
+	if len(bufPool.shards) != runtime.GOMAXPROCS(0) {
+		t.Fatalf("shard count invalid: got %v want %v", len(bufPool.shards),
+			runtime.GOMAXPROCS(0))
+	}
+
+	b := bytes.NewBuffer(make([]byte, 0, 64))
+	bufPool.Put(b)
+	if bufPool.Len() != 1 {
+		t.Fatalf("buffer not retained: got %v want %v", bufPool.Len(), 1)
+	}
+	if got := bufPool.Get(); got.Cap() != 64 {
+		t.Fatalf("buffer capacity invalid: got %v want %v", got.Cap(), 64)
+	}
+}
+
+func BenchmarkPerPBufferPool(b *testing.B) {
+	for _, goroutines := range []int{1, 8, 32, 64} {
+		b.Run(fmt.Sprintf("sharded/%d", goroutines), func(b *testing.B) {
+			benchmarkConcurrent(b, NewShardedBufferPool(runtime.GOMAXPROCS(0), 8, 1024),
+				goroutines)
+		})
+		b.Run(fmt.Sprintf("perp/%d", goroutines), func(b *testing.B) {
+			benchmarkConcurrent(b, NewPerPBufferPool(8, 1024), goroutines)
+		})
+	}
+}
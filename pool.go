@@ -0,0 +1,65 @@
+package bpool
+
+// Pool implements a leaky pool of values of any type in the form of a bounded
+// channel.
+type Pool[T any] struct {
+	c     chan T
+	new   func() T
+	reset func(T)
+}
+
+// NewPool creates a new Pool bounded to the given size. newFn creates a value
+// when none are available in the pool. reset, if not nil, is called on every
+// value returned with Put before it is retained.
+func NewPool[T any](size int, newFn func() T, reset func(T)) (p *Pool[T]) {
+	return &Pool[T]{
+		c:     make(chan T, size),
+		new:   newFn,
+		reset: reset,
+	}
+}
+
+// Resettable is implemented by types that can empty themselves for reuse,
+// such as *bytes.Buffer and *strings.Builder.
+type Resettable interface {
+	Reset()
+}
+
+// NewResettablePool creates a new Pool like NewPool, resetting every value
+// returned with Put by calling its Reset method. Types whose Reset takes an
+// argument, such as *bufio.Writer, can be pooled with NewPool and a reset
+// function supplying it.
+func NewResettablePool[T Resettable](size int, newFn func() T) (p *Pool[T]) {
+	return NewPool(size, newFn, T.Reset)
+}
+
+// Get gets a value from the Pool, or creates a new one if none are available
+// in the pool.
+func (p *Pool[T]) Get() (v T) {
+	select {
+	case v = <-p.c:
+	// reuse existing value
+	default:
+		// create new value
+		v = p.new()
+	}
+	return
+}
+
+// Put returns the given value to the Pool.
+func (p *Pool[T]) Put(v T) {
+	if p.reset != nil {
+		p.reset(v)
+	}
+	select {
+	case p.c <- v:
+		// value went back into pool
+	default:
+		// value didn't go back into pool, just discard
+	}
+}
+
+// Len returns the number of idle values currently held by the pool.
+func (p *Pool[T]) Len() (n int) {
+	return len(p.c)
+}
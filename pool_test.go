@@ -0,0 +1,77 @@
+package bpool
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+type record struct {
+	id   int
+	tags []string
+}
+
+func TestPool(t *testing.T) {
+	var size int = 4
+	var created int
+
+	pool := NewPool(size,
+		func() *record {
+			created++
+			return &record{tags: make([]string, 0, 8)}
+		},
+		func(r *record) {
+			r.id = 0
+			r.tags = r.tags[:0]
+		})
+
+	// Check that a used value comes back reset.
+	r := pool.Get()
+	r.id = 42
+	r.tags = append(r.tags, "a", "b")
+	pool.Put(r)
+
+	r = pool.Get()
+	if r.id != 0 || len(r.tags) != 0 {
+		t.Fatalf("pooled value not reset: got %+v", r)
+	}
+	if created != 1 {
+		t.Fatalf("pooled value not reused: got %v allocations want %v", created, 1)
+	}
+
+	// Fill the pool beyond the capped pool size.
+	for i := 0; i < size*2; i++ {
+		pool.Put(&record{})
+	}
+
+	// Check the size of the pool.
+	if pool.Len() != size {
+		t.Fatalf("pool size invalid: got %v want %v", pool.Len(), size)
+	}
+}
+
+// checkResettablePool writes to a value from pool and checks that it comes
+// back reset and reused.
+func checkResettablePool[T interface {
+	Resettable
+	WriteString(s string) (int, error)
+	Len() int
+}](t *testing.T, pool *Pool[T]) {
+	t.Helper()
+	v := pool.Get()
+	v.WriteString("hello")
+	pool.Put(v)
+
+	if got := pool.Get(); any(got) != any(v) || got.Len() != 0 {
+		t.Fatalf("pooled %T not reset and reused: got len %v", v, got.Len())
+	}
+}
+
+func TestResettablePool(t *testing.T) {
+	checkResettablePool(t, NewResettablePool(4, func() *bytes.Buffer {
+		return new(bytes.Buffer)
+	}))
+	checkResettablePool(t, NewResettablePool(4, func() *strings.Builder {
+		return new(strings.Builder)
+	}))
+}
@@ -0,0 +1,126 @@
+package bpool
+
+import (
+	"bytes"
+	"io"
+	"sync/atomic"
+	"unicode/utf8"
+)
+
+// PooledBuffer is a Buffer obtained from a SizedBufferPool that knows which
+// pool it came from and returns itself to that pool when closed. It
+// implements io.ReadWriteCloser, so a pooled buffer can be handed to code that
+// cleans up through the usual Close contract.
+type PooledBuffer struct {
+	*bytes.Buffer
+	pool   *SizedBufferPool
+	closed atomic.Bool
+
+	// lazy is the capacity to grow to once a write doesn't fit, for buffers
+	// obtained with GetLazy, or 0.
+	lazy int
+}
+
+var _ io.ReadWriteCloser = (*PooledBuffer)(nil)
+
+// GetPooled gets a Buffer from the SizedBufferPool wrapped in a PooledBuffer.
+func (bp *SizedBufferPool) GetPooled() *PooledBuffer {
+	return &PooledBuffer{
+		Buffer: bp.Get(),
+		pool:   bp,
+	}
+}
+
+// GetLazy is like GetPooled, but a reused buffer smaller than the pool's
+// capacity isn't grown to it up front: it is only grown, to the capacity the
+// pool had at the time of GetLazy, on the first write that doesn't fit. This
+// saves the work for callers that often write less than expected. With
+// WithMaxLive the buffer is grown up front like with Get.
+func (bp *SizedBufferPool) GetLazy() *PooledBuffer {
+	if bp.opt.maxLive > 0 {
+		return bp.GetPooled()
+	}
+	bp.count(&bp.s.gets)
+	bp.acquire()
+	if b, ok := bp.take(); ok {
+		return &PooledBuffer{
+			Buffer: bp.prepare(b),
+			pool:   bp,
+			lazy:   bp.Cap(),
+		}
+	}
+	return &PooledBuffer{
+		Buffer: bp.allocLimited(bp.get, nil),
+		pool:   bp,
+	}
+}
+
+// GetWriter is like GetPooled, for callers that use the buffer as an
+// io.WriteCloser.
+func (bp *SizedBufferPool) GetWriter() *PooledBuffer {
+	return bp.GetPooled()
+}
+
+// Acquire gets a Buffer from the SizedBufferPool along with a function that
+// returns it to this pool. Only the first call of release has any effect, so
+// it can be deferred and also called early. The buffer must not be used after
+// release.
+func (bp *SizedBufferPool) Acquire() (b *bytes.Buffer, release func()) {
+	b = bp.Get()
+	var released atomic.Bool
+	return b, func() {
+		if !released.Swap(true) {
+			bp.Put(b)
+		}
+	}
+}
+
+// Close returns the buffer to the pool it came from. Only the first call has
+// any effect; the buffer must not be used after Close.
+func (pb *PooledBuffer) Close() error {
+	if pb.closed.Swap(true) {
+		return nil
+	}
+	pb.pool.Put(pb.Buffer)
+	return nil
+}
+
+// reserve grows a buffer obtained with GetLazy if writing n more bytes
+// wouldn't fit its capacity.
+func (pb *PooledBuffer) reserve(n int) {
+	if pb.lazy == 0 || pb.Available() >= n {
+		return
+	}
+	pb.Grow(max(pb.lazy-pb.Len(), n))
+	pb.lazy = 0
+}
+
+// Write appends p to the buffer like bytes.Buffer.Write.
+func (pb *PooledBuffer) Write(p []byte) (n int, err error) {
+	pb.reserve(len(p))
+	return pb.Buffer.Write(p)
+}
+
+// WriteString appends s to the buffer like bytes.Buffer.WriteString.
+func (pb *PooledBuffer) WriteString(s string) (n int, err error) {
+	pb.reserve(len(s))
+	return pb.Buffer.WriteString(s)
+}
+
+// WriteByte appends c to the buffer like bytes.Buffer.WriteByte.
+func (pb *PooledBuffer) WriteByte(c byte) error {
+	pb.reserve(1)
+	return pb.Buffer.WriteByte(c)
+}
+
+// WriteRune appends r to the buffer like bytes.Buffer.WriteRune.
+func (pb *PooledBuffer) WriteRune(r rune) (n int, err error) {
+	pb.reserve(utf8.RuneLen(r))
+	return pb.Buffer.WriteRune(r)
+}
+
+// ReadFrom reads from r into the buffer like bytes.Buffer.ReadFrom.
+func (pb *PooledBuffer) ReadFrom(r io.Reader) (n int64, err error) {
+	pb.reserve(bytes.MinRead)
+	return pb.Buffer.ReadFrom(r)
+}
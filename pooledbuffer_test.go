@@ -0,0 +1,88 @@
+package bpool
+
+import (
+	"bytes"
+	"io"
+	"testing"
+)
+
+func TestPooledBuffer(t *testing.T) {
+	bufPool := NewSizedBufferPool(4, 64)
+
+	var w io.WriteCloser = bufPool.GetWriter()
+	io.WriteString(w, "hello")
+
+	// Closing twice returns the buffer exactly once.
+	for i := 0; i < 2; i++ {
+		if err := w.Close(); err != nil {
+			t.Fatalf("Close failed: %v", err)
+		}
+	}
+	if bufPool.Len() != 1 {
+		t.Fatalf("buffer not returned once: got %v want %v", bufPool.Len(), 1)
+	}
+	if puts := bufPool.Stats().Puts; puts != 1 {
+		t.Fatalf("buffer returned more than once: got %v puts want %v", puts, 1)
+	}
+}
+
+func TestPooledBufferOrigin(t *testing.T) {
+	a, b := NewSizedBufferPool(4, 64), NewSizedBufferPool(4, 64)
+
+	var rwc io.ReadWriteCloser = b.GetPooled()
+	io.WriteString(rwc, "hello")
+	if data, _ := io.ReadAll(rwc); string(data) != "hello" {
+		t.Fatalf("PooledBuffer read invalid: got %q want %q", data, "hello")
+	}
+	rwc.Close()
+	rwc.Close()
+
+	if a.Len() != 0 || b.Len() != 1 {
+		t.Fatalf("buffer returned to the wrong pool: got %v and %v idle buffers",
+			a.Len(), b.Len())
+	}
+}
+
+func TestSizedBufferPoolAcquire(t *testing.T) {
+	a, b := NewSizedBufferPool(4, 64), NewSizedBufferPool(4, 64)
+
+	buf, release := b.Acquire()
+	buf.WriteString("hello")
+	release()
+	release()
+
+	if a.Len() != 0 || b.Len() != 1 {
+		t.Fatalf("buffer returned to the wrong pool: got %v and %v idle buffers",
+			a.Len(), b.Len())
+	}
+	if puts := b.Stats().Puts; puts != 1 {
+		t.Fatalf("buffer returned more than once: got %v puts want %v", puts, 1)
+	}
+}
+
+func TestSizedBufferPoolGetLazy(t *testing.T) {
+	bufPool := NewSizedBufferPool(2, 64)
+	small := bytes.NewBuffer(make([]byte, 0, 8))
+	bufPool.Put(small)
+
+	pb := bufPool.GetLazy()
+	if pb.Buffer != small || pb.Cap() != 8 {
+		t.Fatalf("lazy buffer grown up front: got cap %v want %v", pb.Cap(), 8)
+	}
+
+	// Writes that fit don't grow the buffer.
+	pb.WriteString("tiny")
+	if pb.Cap() != 8 {
+		t.Fatalf("lazy buffer grown for a tiny write: got cap %v want %v", pb.Cap(), 8)
+	}
+
+	// The first write that doesn't fit grows it to the pool's capacity.
+	pb.Write([]byte("longer than eight"))
+	if pb.Cap() < 64 {
+		t.Fatalf("lazy buffer not grown to capacity: got %v want at least %v", pb.Cap(), 64)
+	}
+	if pb.String() != "tinylonger than eight" {
+		t.Fatalf("lazy buffer contents invalid: got %q", pb.String())
+	}
+	pb.Close()
+}
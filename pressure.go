@@ -0,0 +1,74 @@
+package bpool
+
+import (
+	"math"
+	"runtime"
+	"time"
+)
+
+// HeapAbove returns a memory pressure signal for WithMemoryPressureTrim that
+// reports pressure while the live heap exceeds limit bytes, as measured by
+// runtime.ReadMemStats. Reading the stats briefly stops the world, so the
+// trim interval shouldn't be too short.
+func HeapAbove(limit uint64) func() bool {
+	return func() bool {
+		var m runtime.MemStats
+		runtime.ReadMemStats(&m)
+		return m.HeapAlloc > limit
+	}
+}
+
+// StartTrim starts the goroutine set up with WithMemoryPressureTrim, if it
+// isn't running. Pools created with that option start it right away, so
+// StartTrim only needs to be called to resume after StopTrim. It does nothing
+// on a closed pool.
+func (bp *SizedBufferPool) StartTrim() {
+	if bp.opt.pressure == nil || bp.opt.trimInterval <= 0 || bp.isClosed() {
+		return
+	}
+	bp.trimMu.Lock()
+	defer bp.trimMu.Unlock()
+	if bp.trimStop != nil {
+		return
+	}
+	bp.trimStop = make(chan struct{})
+	bp.trimDone = make(chan struct{})
+	go bp.watchPressure(bp.trimStop, bp.trimDone)
+}
+
+// StopTrim stops the goroutine started by WithMemoryPressureTrim and waits for
+// it to exit. The pool remains usable, but no longer sheds buffers under
+// memory pressure.
+func (bp *SizedBufferPool) StopTrim() {
+	bp.trimMu.Lock()
+	defer bp.trimMu.Unlock()
+	if bp.trimStop != nil {
+		close(bp.trimStop)
+		<-bp.trimDone
+		bp.trimStop = nil
+	}
+}
+
+// watchPressure checks the memory pressure signal every trim interval and
+// trims the pool while it reports pressure, until stop is closed. It closes
+// done when it returns.
+func (bp *SizedBufferPool) watchPressure(stop, done chan struct{}) {
+	defer close(done)
+	ticker := time.NewTicker(bp.opt.trimInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			if bp.opt.pressure() {
+				bp.trim(bp.opt.trimFraction)
+			}
+		case <-stop:
+			return
+		}
+	}
+}
+
+// trim drops the given fraction of the idle buffers, rounded up.
+func (bp *SizedBufferPool) trim(fraction float64) {
+	bp.Trim(int(math.Ceil(fraction * float64(bp.idleLen()))))
+}
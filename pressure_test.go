@@ -0,0 +1,59 @@
+package bpool
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestSizedBufferPoolMemoryPressureTrim(t *testing.T) {
+	var pressure atomic.Bool
+	bufPool := NewSizedBufferPoolWithOptions(WithSize(8), WithAlloc(64),
+		WithMemoryPressureTrim(time.Millisecond, 0.5, pressure.Load))
+	defer bufPool.Close()
+	bufPool.Prefill()
+
+	time.Sleep(10 * time.Millisecond)
+	if bufPool.Len() != 8 {
+		t.Fatalf("pool trimmed without pressure: got %v want %v", bufPool.Len(), 8)
+	}
+
+	pressure.Store(true)
+	deadline := time.Now().Add(time.Second)
+	for bufPool.Len() == 8 {
+		if time.Now().After(deadline) {
+			t.Fatalf("pool not trimmed under pressure")
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	bufPool.StopTrim()
+	bufPool.Prefill()
+	time.Sleep(10 * time.Millisecond)
+	if bufPool.Len() != 8 {
+		t.Fatalf("pool trimmed after StopTrim: got %v want %v", bufPool.Len(), 8)
+	}
+}
+
+func TestSizedBufferPoolTrim(t *testing.T) {
+	bufPool := NewSizedBufferPool(8, 64)
+	bufPool.Prefill()
+
+	bufPool.trim(0.5)
+	if bufPool.Len() != 4 {
+		t.Fatalf("trim invalid: got %v want %v", bufPool.Len(), 4)
+	}
+	bufPool.trim(0.1)
+	if bufPool.Len() != 3 {
+		t.Fatalf("trim not rounded up: got %v want %v", bufPool.Len(), 3)
+	}
+}
+
+func TestHeapAbove(t *testing.T) {
+	if !HeapAbove(0)() {
+		t.Fatalf("no pressure above an empty heap")
+	}
+	if HeapAbove(^uint64(0))() {
+		t.Fatalf("pressure below the largest heap")
+	}
+}
@@ -0,0 +1,22 @@
+//go:build !purego
+
+package bpool
+
+import (
+	_ "unsafe" // for go:linkname
+)
+
+//go:linkname runtime_procPin runtime.procPin
+func runtime_procPin() int
+
+//go:linkname runtime_procUnpin runtime.procUnpin
+func runtime_procUnpin()
+
+// procID returns the id of the P the calling goroutine runs on. The
+// goroutine is only pinned while reading it, so it may have migrated by the
+// time the id is used; that only costs some locality.
+func procID() int {
+	id := runtime_procPin()
+	runtime_procUnpin()
+	return id
+}
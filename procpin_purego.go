@@ -0,0 +1,14 @@
+//go:build purego
+
+package bpool
+
+import (
+	"math/rand/v2"
+)
+
+// procID returns a random number in place of the id of the P the calling
+// goroutine runs on, for builds that avoid go:linkname. The runtime's random
+// source is per thread, so this is cheap and doesn't contend either.
+func procID() int {
+	return int(rand.Uint32() >> 1)
+}
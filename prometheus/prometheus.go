@@ -0,0 +1,59 @@
+// Package prometheus exports the stats of a bpool.SizedBufferPool as a
+// Prometheus collector. It lives in its own package so that bpool itself
+// doesn't depend on the Prometheus client library; without it,
+// SizedBufferPool.WriteMetrics writes the same metrics in the text
+// exposition format.
+package prometheus
+
+import (
+	"github.com/oxtoacart/bpool"
+	prom "github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	getsDesc = prom.NewDesc("bpool_gets_total",
+		"Calls to Get.", []string{"pool"}, nil)
+	missesDesc = prom.NewDesc("bpool_misses_total",
+		"Gets that had to allocate a new buffer.", []string{"pool"}, nil)
+	discardsDesc = prom.NewDesc("bpool_discards_total",
+		"Puts that threw the buffer away.", []string{"pool"}, nil)
+	idleDesc = prom.NewDesc("bpool_idle_buffers",
+		"Idle buffers held by the pool.", []string{"pool"}, nil)
+	capDesc = prom.NewDesc("bpool_cap_bytes",
+		"Base capacity of new buffers in bytes.", []string{"pool"}, nil)
+)
+
+// collector implements prom.Collector for a pool.
+type collector struct {
+	bp   *bpool.SizedBufferPool
+	name string
+}
+
+// Collector returns a collector exporting the stats of bp, labelled with
+// pool="poolName": bpool_gets_total, bpool_misses_total and
+// bpool_discards_total as counters, and bpool_idle_buffers and
+// bpool_cap_bytes as gauges. The stats are read atomically on each scrape
+// without locking the pool. Register one collector per pool, with distinct
+// names.
+func Collector(bp *bpool.SizedBufferPool, poolName string) prom.Collector {
+	return &collector{bp: bp, name: poolName}
+}
+
+// Describe implements prom.Collector.
+func (c *collector) Describe(ch chan<- *prom.Desc) {
+	ch <- getsDesc
+	ch <- missesDesc
+	ch <- discardsDesc
+	ch <- idleDesc
+	ch <- capDesc
+}
+
+// Collect implements prom.Collector.
+func (c *collector) Collect(ch chan<- prom.Metric) {
+	s := c.bp.Stats()
+	ch <- prom.MustNewConstMetric(getsDesc, prom.CounterValue, float64(s.Gets), c.name)
+	ch <- prom.MustNewConstMetric(missesDesc, prom.CounterValue, float64(s.Misses), c.name)
+	ch <- prom.MustNewConstMetric(discardsDesc, prom.CounterValue, float64(s.Discards), c.name)
+	ch <- prom.MustNewConstMetric(idleDesc, prom.GaugeValue, float64(c.bp.Len()), c.name)
+	ch <- prom.MustNewConstMetric(capDesc, prom.GaugeValue, float64(c.bp.Cap()), c.name)
+}
@@ -0,0 +1,40 @@
+package prometheus
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/oxtoacart/bpool"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestCollector(t *testing.T) {
+	bufPool := bpool.NewSizedBufferPool(1, 64)
+	a, b := bufPool.Get(), bufPool.Get()
+	bufPool.Put(a)
+	bufPool.Put(b)
+
+	want := `
+# HELP bpool_gets_total Calls to Get.
+# TYPE bpool_gets_total counter
+bpool_gets_total{pool="api"} 2
+# HELP bpool_misses_total Gets that had to allocate a new buffer.
+# TYPE bpool_misses_total counter
+bpool_misses_total{pool="api"} 2
+# HELP bpool_discards_total Puts that threw the buffer away.
+# TYPE bpool_discards_total counter
+bpool_discards_total{pool="api"} 1
+# HELP bpool_idle_buffers Idle buffers held by the pool.
+# TYPE bpool_idle_buffers gauge
+bpool_idle_buffers{pool="api"} 1
+# HELP bpool_cap_bytes Base capacity of new buffers in bytes.
+# TYPE bpool_cap_bytes gauge
+bpool_cap_bytes{pool="api"} 64
+`
+	if err := testutil.CollectAndCompare(Collector(bufPool, "api"), strings.NewReader(want)); err != nil {
+		t.Fatalf("collected metrics invalid: %v", err)
+	}
+	if n := testutil.CollectAndCount(Collector(bufPool, "api")); n != 5 {
+		t.Fatalf("metric count invalid: got %v want %v", n, 5)
+	}
+}
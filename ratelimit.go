@@ -0,0 +1,55 @@
+package bpool
+
+import (
+	"sync"
+	"time"
+)
+
+// WithAllocRateLimit limits the rate at which Get and its variants allocate
+// new buffers to perSecond, allowing bursts of a tenth of a second's worth, as
+// a safety valve against runaway allocation. A Get over the limit waits for
+// its turn, taking a buffer returned in the meantime instead, if any; Gets
+// served from the pool are never limited. Put never waits: it doesn't replace
+// an oversized or aliased buffer while over the limit. Prefill, the buffers
+// kept with WithMinIdle and growing reused buffers aren't limited. It has no
+// effect together with WithMaxLive, and a perSecond of 0 disables the limit.
+func WithAllocRateLimit(perSecond int) Option {
+	return func(o *options) {
+		o.allocRate = perSecond
+	}
+}
+
+// tokenBucket implements the rate limit set with WithAllocRateLimit.
+type tokenBucket struct {
+	mu     sync.Mutex
+	rate   float64 // tokens added per second
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+func newTokenBucket(perSecond int, now time.Time) *tokenBucket {
+	burst := max(float64(perSecond)/10, 1)
+	return &tokenBucket{
+		rate:   float64(perSecond),
+		burst:  burst,
+		tokens: burst,
+		last:   now,
+	}
+}
+
+// take takes a token and returns 0 if one is available at now, or returns how
+// long to wait for the next one otherwise.
+func (tb *tokenBucket) take(now time.Time) time.Duration {
+	tb.mu.Lock()
+	defer tb.mu.Unlock()
+	if now.After(tb.last) {
+		tb.tokens = min(tb.burst, tb.tokens+now.Sub(tb.last).Seconds()*tb.rate)
+		tb.last = now
+	}
+	if tb.tokens >= 1 {
+		tb.tokens--
+		return 0
+	}
+	return max(time.Duration((1-tb.tokens)/tb.rate*float64(time.Second)), 1)
+}
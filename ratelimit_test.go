@@ -0,0 +1,79 @@
+package bpool
+
+import (
+	"bytes"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestSizedBufferPoolAllocRateLimit(t *testing.T) {
+	const perSecond, burst = 100, 10
+	bufPool := NewSizedBufferPoolWithOptions(WithSize(1), WithAlloc(64),
+		WithAllocRateLimit(perSecond))
+
+	start := time.Now()
+	deadline := start.Add(200 * time.Millisecond)
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for time.Now().Before(deadline) {
+				bufPool.Get()
+			}
+		}()
+	}
+	wg.Wait()
+
+	elapsed := time.Since(start).Seconds()
+	allocs := bufPool.Stats().Misses
+	if limit := uint64(burst + perSecond*elapsed); allocs > limit || allocs < burst {
+		t.Fatalf("allocations in %.3fs invalid: got %v want within [%v, %v]", elapsed,
+			allocs, burst, limit)
+	}
+}
+
+func TestSizedBufferPoolAllocRateLimitVariants(t *testing.T) {
+	const perSecond, burst = 20, 2
+	for name, get := range getVariants {
+		bufPool := NewSizedBufferPoolWithOptions(WithSize(1), WithAlloc(64),
+			WithAllocRateLimit(perSecond), WithTracer(&fakeTracer{}))
+		start := time.Now()
+		for i := 0; i < burst+2; i++ {
+			get(bufPool)
+		}
+		if elapsed, want := time.Since(start), 2*time.Second/perSecond*3/4; elapsed < want {
+			t.Fatalf("%v: allocations not rate limited: got %v want at least %v", name,
+				elapsed, want)
+		}
+	}
+
+	// Put doesn't wait to replace an oversized buffer, and Prefill isn't
+	// limited.
+	bufPool := NewSizedBufferPoolWithOptions(WithSize(2), WithAlloc(64),
+		WithMaxCap(64), WithAllocRateLimit(perSecond))
+	bufPool.GetN(burst)
+	bufPool.Put(bytes.NewBuffer(make([]byte, 0, 1024)))
+	if n := bufPool.Stats().PutReplaceAllocs; n != 0 {
+		t.Fatalf("replacements over the limit invalid: got %v want %v", n, 0)
+	}
+	bufPool.Prefill()
+	if n := bufPool.Len(); n != 2 {
+		t.Fatalf("len after prefill invalid: got %v want %v", n, 2)
+	}
+}
+
+func TestTokenBucket(t *testing.T) {
+	now := time.Unix(0, 0)
+	tb := newTokenBucket(10, now)
+	if d := tb.take(now); d != 0 {
+		t.Fatalf("first take invalid: got %v want %v", d, 0)
+	}
+	if d := tb.take(now); d != 100*time.Millisecond {
+		t.Fatalf("take of empty bucket invalid: got %v want %v", d, 100*time.Millisecond)
+	}
+	if d := tb.take(now.Add(100 * time.Millisecond)); d != 0 {
+		t.Fatalf("take after refill invalid: got %v want %v", d, 0)
+	}
+}
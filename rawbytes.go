@@ -0,0 +1,98 @@
+package bpool
+
+import (
+	"bytes"
+	"runtime"
+	"unsafe"
+)
+
+// bytesEntry records a buffer whose array backs a slice handed out by
+// GetBytes. The buffer is emptied while the slice is out, so that the pool
+// doesn't keep the array reachable and notices when the caller drops it.
+type bytesEntry struct {
+	b       *bytes.Buffer
+	cap     int
+	cleanup runtime.Cleanup
+}
+
+// GetBytes gets an empty []byte from the SizedBufferPool, backed by a pooled
+// buffer, so the same pool can serve raw byte slices. The slice must be
+// returned with PutBytes rather than Put.
+func (bp *SizedBufferPool) GetBytes() []byte {
+	b := bp.Get()
+	if b.Cap() == 0 {
+		// An empty backing array has no address to track the buffer by.
+		b.Grow(1)
+	}
+	p := b.Bytes()[:0]
+	data := unsafe.SliceData(p[:1])
+	key := uintptr(unsafe.Pointer(data))
+	*b = bytes.Buffer{}
+	bp.bytesMu.Lock()
+	if bp.bytesOwner == nil {
+		bp.bytesOwner = make(map[uintptr]bytesEntry)
+	}
+	bp.bytesOwner[key] = bytesEntry{b: b, cap: cap(p),
+		cleanup: bp.watchBytes(data, bytesRef{key, b})}
+	bp.bytesMu.Unlock()
+	return p
+}
+
+// PutBytes returns a slice obtained from GetBytes to the SizedBufferPool. The
+// slice may have any length but must start at the backing array returned by
+// GetBytes: appending past its capacity reallocates it, so keep the capacity
+// in mind or copy the data into the original slice before returning it. A
+// slice that isn't recognized is counted in Stats.UnmatchedPutBytes and
+// ignored; its original buffer is no longer counted as outstanding, and the
+// pool forgets it once the original slice has been garbage collected.
+func (bp *SizedBufferPool) PutBytes(p []byte) {
+	if cap(p) == 0 {
+		return
+	}
+	data := unsafe.SliceData(p[:1])
+	key := uintptr(unsafe.Pointer(data))
+	bp.bytesMu.Lock()
+	e, ok := bp.bytesOwner[key]
+	delete(bp.bytesOwner, key)
+	bp.bytesMu.Unlock()
+	if !ok {
+		// The original buffer is lost with the caller's reference to it;
+		// don't let it hold up CloseAndWait.
+		bp.count(&bp.s.unmatched)
+		bp.release()
+		return
+	}
+	e.cleanup.Stop()
+	*e.b = *bytes.NewBuffer(unsafe.Slice(data, e.cap)[:0])
+	bp.Put(e.b)
+}
+
+// bytesRef identifies the entry of a slice handed out by GetBytes.
+type bytesRef struct {
+	key uintptr
+	b   *bytes.Buffer
+}
+
+// watchBytes arranges for the entry of the slice starting at data to be
+// dropped once its array has been garbage collected, which is the earliest
+// the pool can tell that a slice passed to PutBytes wasn't recognized because
+// it outgrew that array. Arrays the garbage collector doesn't manage, e.g.
+// memory-mapped ones, can't be watched, and neither can arrays an Allocator
+// record keeps reachable: their entries stay.
+func (bp *SizedBufferPool) watchBytes(data *byte, ref bytesRef) (c runtime.Cleanup) {
+	defer func() {
+		// AddCleanup panics on memory outside the Go heap.
+		recover()
+	}()
+	return runtime.AddCleanup(data, bp.forgetBytes, ref)
+}
+
+// forgetBytes drops the entry of a slice whose array has been garbage
+// collected, unless a new slice has since been handed out at that address.
+func (bp *SizedBufferPool) forgetBytes(ref bytesRef) {
+	bp.bytesMu.Lock()
+	if e, ok := bp.bytesOwner[ref.key]; ok && e.b == ref.b {
+		delete(bp.bytesOwner, ref.key)
+	}
+	bp.bytesMu.Unlock()
+}
@@ -0,0 +1,76 @@
+package bpool
+
+import (
+	"runtime"
+	"testing"
+	"time"
+)
+
+func TestSizedBufferPoolGetBytes(t *testing.T) {
+	bufPool := NewSizedBufferPool(2, 64)
+
+	p := bufPool.GetBytes()
+	if len(p) != 0 || cap(p) != 64 {
+		t.Fatalf("slice invalid: got len %v cap %v want len 0 cap 64", len(p), cap(p))
+	}
+	p = append(p, payload[:32]...)
+	bufPool.PutBytes(p)
+	if bufPool.Len() != 1 {
+		t.Fatalf("buffer not returned: got %v idle want %v", bufPool.Len(), 1)
+	}
+
+	// The round-trip returned the buffer backing the slice.
+	b := bufPool.Get()
+	if &b.Bytes()[:1][0] != &p[0] {
+		t.Fatalf("returned buffer doesn't back the slice")
+	}
+	bufPool.Put(b)
+
+	// Slices that grew out of their buffer aren't recognized.
+	p = bufPool.GetBytes()
+	p = append(p, payload...)
+	bufPool.PutBytes(p)
+	bufPool.PutBytes(nil)
+	if bufPool.Len() != 0 {
+		t.Fatalf("reallocated slice returned a buffer: got %v idle want 0", bufPool.Len())
+	}
+	if n := bufPool.Stats().UnmatchedPutBytes; n != 1 {
+		t.Fatalf("unmatched slices invalid: got %v want %v", n, 1)
+	}
+	if n := bufPool.o.Load(); n != 0 {
+		t.Fatalf("lost buffer still outstanding: got %v want %v", n, 0)
+	}
+}
+
+// TestSizedBufferPoolPutBytesUnmatchedForget checks that the pool forgets the
+// buffer of a slice that outgrew it once the original slice is collected.
+func TestSizedBufferPoolPutBytesUnmatchedForget(t *testing.T) {
+	bufPool := NewSizedBufferPool(2, 64)
+	func() {
+		p := bufPool.GetBytes()
+		bufPool.PutBytes(append(p, payload...))
+	}()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		bufPool.bytesMu.Lock()
+		n := len(bufPool.bytesOwner)
+		bufPool.bytesMu.Unlock()
+		if n == 0 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("unmatched slice's buffer still referenced: got %v entries want 0", n)
+		}
+		runtime.GC()
+		time.Sleep(time.Millisecond)
+	}
+
+	// A matched slice is still returned after a collection.
+	p := bufPool.GetBytes()
+	runtime.GC()
+	bufPool.PutBytes(append(p, payload[:32]...))
+	if bufPool.Len() != 1 {
+		t.Fatalf("buffer not returned: got %v idle want %v", bufPool.Len(), 1)
+	}
+}
@@ -0,0 +1,55 @@
+package bpool
+
+import (
+	"bytes"
+	"io"
+	"sync/atomic"
+)
+
+// GetFilled gets a Buffer from the SizedBufferPool and reads r into it until
+// EOF. If r reports its remaining length through a Len method, like
+// bytes.Reader and strings.Reader do, the buffer is sized for it up front.
+// On a read error the buffer is returned to the pool and nil is returned
+// along with the error.
+func (bp *SizedBufferPool) GetFilled(r io.Reader) (*bytes.Buffer, error) {
+	var b *bytes.Buffer
+	if l, ok := r.(interface{ Len() int }); ok {
+		b = bp.GetWithHint(l.Len())
+	} else {
+		b = bp.Get()
+	}
+
+	if _, err := b.ReadFrom(r); err != nil {
+		bp.Put(b)
+		return nil, err
+	}
+	return b, nil
+}
+
+// GetReader copies data into a Buffer from the SizedBufferPool and returns a
+// read-only view of it, so that code which only reads can't write to pooled
+// storage, along with a function that returns the buffer to this pool. Only
+// the first call of release has any effect; the reader must not be used
+// after release.
+func (bp *SizedBufferPool) GetReader(data []byte) (r io.Reader, release func()) {
+	b := bp.GetGrow(len(data))
+	b.Reset()
+	b.Write(data)
+	var released atomic.Bool
+	return bytes.NewReader(b.Bytes()), func() {
+		if !released.Swap(true) {
+			bp.Put(b)
+		}
+	}
+}
+
+// Pipe copies src to dst like io.Copy, using a Buffer from the
+// SizedBufferPool as scratch space instead of allocating one. The buffer is
+// returned to the pool afterwards, even on error. As with io.CopyBuffer, the
+// scratch space isn't used if src implements io.WriterTo or dst implements
+// io.ReaderFrom.
+func (bp *SizedBufferPool) Pipe(dst io.Writer, src io.Reader) (int64, error) {
+	b := bp.GetGrow(1)
+	defer bp.Put(b)
+	return io.CopyBuffer(dst, src, b.Bytes()[:b.Cap()])
+}
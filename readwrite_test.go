@@ -0,0 +1,82 @@
+package bpool
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestSizedBufferPoolGetReader(t *testing.T) {
+	bufPool := NewSizedBufferPool(4, 16)
+
+	data := strings.Repeat("hello", 10)
+	r, release := bufPool.GetReader([]byte(data))
+	if got, err := io.ReadAll(r); err != nil || string(got) != data {
+		t.Fatalf("GetReader invalid: got %q, %v want %q", got, err, data)
+	}
+	if _, ok := r.(io.Writer); ok {
+		t.Fatalf("GetReader returned a writable view")
+	}
+	release()
+	release()
+	if bufPool.Len() != 1 {
+		t.Fatalf("buffer not returned on release: got %v want %v", bufPool.Len(), 1)
+	}
+}
+
+func TestSizedBufferPoolGetFilled(t *testing.T) {
+	bufPool := NewSizedBufferPool(4, 16)
+
+	data := strings.Repeat("hello", 100)
+	b, err := bufPool.GetFilled(strings.NewReader(data))
+	if err != nil || b.String() != data {
+		t.Fatalf("GetFilled invalid: got %q, %v want %q", b, err, data)
+	}
+	bufPool.Put(b)
+
+	// A failing reader returns the buffer to the pool.
+	errRead := errors.New("read failed")
+	r := io.MultiReader(strings.NewReader("partial"), &failingReader{errRead})
+	b, err = bufPool.GetFilled(r)
+	if b != nil || err != errRead {
+		t.Fatalf("GetFilled on failing reader: got %v, %v want nil, %v", b, err,
+			errRead)
+	}
+	if bufPool.Len() != 1 {
+		t.Fatalf("buffer not returned on error: got %v want %v", bufPool.Len(), 1)
+	}
+}
+
+type failingReader struct {
+	err error
+}
+
+func (r *failingReader) Read(p []byte) (int, error) {
+	return 0, r.err
+}
+
+func TestSizedBufferPoolPipe(t *testing.T) {
+	bufPool := NewSizedBufferPool(1, 64)
+
+	// Hide the WriterTo and ReaderFrom methods so the scratch buffer is used.
+	data := strings.Repeat("0123456789", 10000)
+	var dst strings.Builder
+	n, err := bufPool.Pipe(struct{ io.Writer }{&dst},
+		struct{ io.Reader }{strings.NewReader(data)})
+	if err != nil || n != int64(len(data)) || dst.String() != data {
+		t.Fatalf("Pipe invalid: got %v bytes, %v want %v bytes", n, err, len(data))
+	}
+	if bufPool.Len() != 1 {
+		t.Fatalf("buffer not returned: got %v want %v", bufPool.Len(), 1)
+	}
+
+	// A failing reader still returns the buffer.
+	errRead := errors.New("read failed")
+	if _, err := bufPool.Pipe(io.Discard, &failingReader{errRead}); err != errRead {
+		t.Fatalf("Pipe on failing reader: got %v want %v", err, errRead)
+	}
+	if got := bufPool.Stats(); got.Puts != 2 || bufPool.Len() != 1 {
+		t.Fatalf("buffer not returned on error: got %+v", got)
+	}
+}
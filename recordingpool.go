@@ -0,0 +1,90 @@
+package bpool
+
+import (
+	"bytes"
+	"slices"
+	"sync"
+)
+
+// EventOp is the kind of an Event recorded by a RecordingPool.
+type EventOp int
+
+const (
+	// OpGet is a call to Get.
+	OpGet EventOp = iota
+	// OpPut is a call to Put.
+	OpPut
+)
+
+// String returns the name of the operation.
+func (op EventOp) String() string {
+	switch op {
+	case OpGet:
+		return "Get"
+	case OpPut:
+		return "Put"
+	}
+	return "unknown"
+}
+
+// Event is a Get or Put recorded by a RecordingPool, along with the buffer it
+// handed out or received.
+type Event struct {
+	Op     EventOp
+	Buffer *bytes.Buffer
+}
+
+// RecordingPool is a BufferPooler that records every Get and Put made through
+// it before passing them on to another BufferPooler, so that tests of code
+// using a pool can assert how it did, e.g. that every buffer was returned.
+// A RecordingPool is safe for concurrent use.
+type RecordingPool struct {
+	pool BufferPooler
+
+	mu          sync.Mutex
+	events      []Event
+	outstanding map[*bytes.Buffer]bool
+}
+
+// NewRecordingPool creates a new RecordingPool wrapping pool. Use NopPool to
+// record without pooling.
+func NewRecordingPool(pool BufferPooler) *RecordingPool {
+	return &RecordingPool{
+		pool:        pool,
+		outstanding: make(map[*bytes.Buffer]bool),
+	}
+}
+
+// Get gets a Buffer from the wrapped pool and records it.
+func (rp *RecordingPool) Get() *bytes.Buffer {
+	b := rp.pool.Get()
+	rp.mu.Lock()
+	rp.events = append(rp.events, Event{OpGet, b})
+	rp.outstanding[b] = true
+	rp.mu.Unlock()
+	return b
+}
+
+// Put records the given Buffer and returns it to the wrapped pool.
+func (rp *RecordingPool) Put(b *bytes.Buffer) {
+	rp.mu.Lock()
+	rp.events = append(rp.events, Event{OpPut, b})
+	delete(rp.outstanding, b)
+	rp.mu.Unlock()
+	rp.pool.Put(b)
+}
+
+// Outstanding returns the number of buffers handed out by Get and not
+// returned with Put yet.
+func (rp *RecordingPool) Outstanding() int {
+	rp.mu.Lock()
+	defer rp.mu.Unlock()
+	return len(rp.outstanding)
+}
+
+// Events returns a copy of the events recorded so far, oldest first.
+func (rp *RecordingPool) Events() []Event {
+	rp.mu.Lock()
+	defer rp.mu.Unlock()
+	return slices.Clone(rp.events)
+}
@@ -0,0 +1,39 @@
+package bpool
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestRecordingPool(t *testing.T) {
+	pool := NewRecordingPool(NewSizedBufferPool(4, 64))
+
+	// A handler that forgets to return its second buffer.
+	handle := func(pool BufferPooler) {
+		a, b := pool.Get(), pool.Get()
+		a.WriteString("header")
+		b.WriteString("body")
+		pool.Put(a)
+	}
+	handle(pool)
+
+	if n := pool.Outstanding(); n != 1 {
+		t.Fatalf("missing Put not detected: got %v outstanding want %v", n, 1)
+	}
+	events := pool.Events()
+	ops := make([]EventOp, len(events))
+	for i, e := range events {
+		ops[i] = e.Op
+	}
+	if want := []EventOp{OpGet, OpGet, OpPut}; !slices.Equal(ops, want) {
+		t.Fatalf("events invalid: got %v want %v", ops, want)
+	}
+	if events[2].Buffer != events[0].Buffer {
+		t.Fatalf("Put recorded for the wrong buffer")
+	}
+
+	pool.Put(events[1].Buffer)
+	if n := pool.Outstanding(); n != 0 {
+		t.Fatalf("outstanding buffers invalid: got %v want %v", n, 0)
+	}
+}
@@ -0,0 +1,89 @@
+package bpool
+
+import (
+	"bytes"
+)
+
+// buffers is the bounded channel holding the idle buffers of a
+// SizedBufferPool. Resizing the pool replaces it as a whole, closing replaced
+// to wake up goroutines blocked on the old channel.
+type buffers struct {
+	c        chan *bytes.Buffer
+	replaced chan struct{}
+}
+
+func newBuffers(size int) *buffers {
+	return &buffers{
+		c:        make(chan *bytes.Buffer, size),
+		replaced: make(chan struct{}),
+	}
+}
+
+// Resize changes the number of buffers retained in the pool. Idle buffers
+// are moved over to the resized pool; when shrinking, those that don't fit
+// are discarded, counting the one moved aside by PeekCap. Resize is safe to
+// call while the pool is in use.
+func (bp *SizedBufferPool) Resize(size int) {
+	bp.resizeMu.Lock()
+	defer bp.resizeMu.Unlock()
+
+	if bp.lifo != nil {
+		for _, b := range bp.lifo.resize(size) {
+			bp.evict(b)
+		}
+		return
+	}
+
+	old := bp.cur.Swap(newBuffers(size))
+	close(old.replaced)
+
+	c := bp.ch()
+	for {
+		select {
+		case b := <-old.c:
+			select {
+			case c <- b:
+			default:
+				bp.evict(b)
+			}
+		default:
+			bp.peekMu.Lock()
+			bp.fitPeeked(c)
+			bp.peekMu.Unlock()
+			return
+		}
+	}
+}
+
+// Rotate atomically replaces the pool's idle buffers with an empty set and
+// releases the old ones, including the one moved aside by PeekCap, e.g. for a
+// controlled memory reset. Unlike Drain,
+// which takes the idle buffers one by one, no buffer returned concurrently
+// can be released: buffers returned with Put after Rotate, including those
+// handed out before it, fill the new set as usual.
+func (bp *SizedBufferPool) Rotate() {
+	bp.resizeMu.Lock()
+	defer bp.resizeMu.Unlock()
+
+	if bp.lifo != nil {
+		for _, b := range bp.lifo.empty() {
+			bp.evict(b)
+		}
+		return
+	}
+
+	old := bp.cur.Swap(newBuffers(bp.idleCap()))
+	close(old.replaced)
+	bp.visitPeeked(func(b *bytes.Buffer) bool {
+		bp.evict(b)
+		return false
+	})
+	for {
+		select {
+		case b := <-old.c:
+			bp.evict(b)
+		default:
+			return
+		}
+	}
+}
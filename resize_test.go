@@ -0,0 +1,89 @@
+package bpool
+
+import (
+	"bytes"
+	"sync"
+	"testing"
+)
+
+func TestSizedBufferPoolResize(t *testing.T) {
+	bufPool := NewSizedBufferPool(4, 64)
+	for i := 0; i < 4; i++ {
+		bufPool.Put(bytes.NewBuffer(make([]byte, 0, 64)))
+	}
+
+	// Growing keeps the idle buffers and makes room for more.
+	bufPool.Resize(8)
+	if bufPool.Len() != 4 || cap(bufPool.ch()) != 8 {
+		t.Fatalf("grown pool invalid: got len %v cap %v want len %v cap %v",
+			bufPool.Len(), cap(bufPool.ch()), 4, 8)
+	}
+
+	// Shrinking drops the idle buffers that no longer fit.
+	bufPool.Resize(2)
+	if bufPool.Len() != 2 || cap(bufPool.ch()) != 2 {
+		t.Fatalf("shrunk pool invalid: got len %v cap %v want len %v cap %v",
+			bufPool.Len(), cap(bufPool.ch()), 2, 2)
+	}
+}
+
+func TestSizedBufferPoolResizeConcurrent(t *testing.T) {
+	bufPool := NewSizedBufferPool(4, 64)
+
+	var wg sync.WaitGroup
+	stop := make(chan struct{})
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-stop:
+					return
+				default:
+				}
+				b := bufPool.Get()
+				b.WriteString("hello")
+				bufPool.Put(b)
+			}
+		}()
+	}
+
+	for i := 0; i < 100; i++ {
+		bufPool.Resize(1 + i%16)
+	}
+	close(stop)
+	wg.Wait()
+
+	if bufPool.Len() > cap(bufPool.ch()) || cap(bufPool.ch()) != 4 {
+		t.Fatalf("resized pool invalid: got len %v cap %v", bufPool.Len(),
+			cap(bufPool.ch()))
+	}
+}
+
+func TestSizedBufferPoolRotate(t *testing.T) {
+	for _, lifo := range []bool{false, true} {
+		bufPool := NewSizedBufferPoolWithOptions(WithSize(4), WithAlloc(64),
+			WithLIFO(lifo))
+		old := bufPool.GetN(2)
+		outstanding := bufPool.Get()
+		bufPool.PutN(old)
+
+		bufPool.Rotate()
+		if bufPool.Len() != 0 || bufPool.idleCap() != 4 {
+			t.Fatalf("lifo %v: old idle buffers not released: got len %v cap %v",
+				lifo, bufPool.Len(), bufPool.idleCap())
+		}
+
+		// A buffer handed out before the rotation populates the new set.
+		bufPool.Put(outstanding)
+		if got := bufPool.Get(); got != outstanding {
+			t.Fatalf("lifo %v: outstanding buffer not retained after Rotate", lifo)
+		}
+		for _, b := range old {
+			if got := bufPool.Get(); got == b {
+				t.Fatalf("lifo %v: released buffer reused", lifo)
+			}
+		}
+	}
+}
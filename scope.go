@@ -0,0 +1,43 @@
+package bpool
+
+import (
+	"bytes"
+	"sync"
+)
+
+// Scope ties buffers from a SizedBufferPool to a lifetime, e.g. that of a
+// request: every buffer obtained from the Scope is returned to the pool at
+// once by Release. A Scope is safe for concurrent use.
+type Scope struct {
+	pool *SizedBufferPool
+
+	mu   sync.Mutex
+	bufs []*bytes.Buffer
+}
+
+// NewScope creates a new Scope getting its buffers from the SizedBufferPool.
+func (bp *SizedBufferPool) NewScope() *Scope {
+	return &Scope{pool: bp}
+}
+
+// Get gets a Buffer from the pool and registers it with the Scope. The buffer
+// must not be returned to the pool by other means.
+func (s *Scope) Get() *bytes.Buffer {
+	b := s.pool.Get()
+	s.mu.Lock()
+	s.bufs = append(s.bufs, b)
+	s.mu.Unlock()
+	return b
+}
+
+// Release returns all buffers obtained from the Scope since the last Release
+// to the pool. Calling it again has no effect unless more buffers were
+// obtained in between, so it can be deferred and also called early. The
+// buffers must not be used after Release.
+func (s *Scope) Release() {
+	s.mu.Lock()
+	bufs := s.bufs
+	s.bufs = nil
+	s.mu.Unlock()
+	s.pool.PutN(bufs)
+}
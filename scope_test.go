@@ -0,0 +1,26 @@
+package bpool
+
+import (
+	"testing"
+)
+
+func TestScope(t *testing.T) {
+	bufPool := NewSizedBufferPool(4, 64)
+
+	scope := bufPool.NewScope()
+	for i := 0; i < 3; i++ {
+		scope.Get().WriteString("hello")
+	}
+	if bufPool.Len() != 0 {
+		t.Fatalf("buffers returned before Release: got %v idle", bufPool.Len())
+	}
+
+	scope.Release()
+	scope.Release()
+	if bufPool.Len() != 3 {
+		t.Fatalf("buffers not returned on Release: got %v want %v", bufPool.Len(), 3)
+	}
+	if puts := bufPool.Stats().Puts; puts != 3 {
+		t.Fatalf("buffers returned more than once: got %v puts want %v", puts, 3)
+	}
+}
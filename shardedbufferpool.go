@@ -0,0 +1,62 @@
+package bpool
+
+import (
+	"bytes"
+	"math/rand/v2"
+)
+
+// ShardedBufferPool spreads buffers over several SizedBufferPools to reduce
+// contention on a single channel when many goroutines Get and Put at once.
+// Each operation starts at a randomly chosen shard, so concurrent callers
+// mostly touch different channels.
+type ShardedBufferPool struct {
+	shards []*SizedBufferPool
+}
+
+// NewShardedBufferPool creates a new ShardedBufferPool of shards pools, each
+// retaining up to sizePerShard buffers with an initial capacity of alloc. A
+// shards count below 1 is treated as 1.
+func NewShardedBufferPool(shards int, sizePerShard int, alloc int) (bp *ShardedBufferPool) {
+	bp = &ShardedBufferPool{
+		shards: make([]*SizedBufferPool, max(shards, 1)),
+	}
+	for i := range bp.shards {
+		bp.shards[i] = NewSizedBufferPool(sizePerShard, alloc)
+	}
+	return
+}
+
+// Get gets a Buffer from the ShardedBufferPool. It tries every shard starting
+// from a random one before creating a new buffer.
+func (bp *ShardedBufferPool) Get() *bytes.Buffer {
+	n := len(bp.shards)
+	start := rand.IntN(n)
+	for i := 0; i < n; i++ {
+		if b, ok := bp.shards[(start+i)%n].TryGet(); ok {
+			return b
+		}
+	}
+	return bp.shards[start].Get()
+}
+
+// Put returns the given Buffer to the first shard with room for it, starting
+// from a random one. The buffer is discarded if every shard is full.
+func (bp *ShardedBufferPool) Put(b *bytes.Buffer) {
+	n := len(bp.shards)
+	start := rand.IntN(n)
+	for i := 0; i < n; i++ {
+		if shard := bp.shards[(start+i)%n]; shard.Len() < shard.idleCap() {
+			shard.Put(b)
+			return
+		}
+	}
+	bp.shards[start].Put(b)
+}
+
+// Len returns the number of idle buffers currently held by all shards.
+func (bp *ShardedBufferPool) Len() (n int) {
+	for _, shard := range bp.shards {
+		n += shard.Len()
+	}
+	return
+}
@@ -0,0 +1,71 @@
+package bpool
+
+import (
+	"bytes"
+	"fmt"
+	"sync"
+	"testing"
+)
+
+func TestShardedBufferPoolNoShards(t *testing.T) {
+	for _, shards := range []int{0, -1} {
+		bufPool := NewShardedBufferPool(shards, 4, 64)
+		bufPool.Put(bufPool.Get())
+		if bufPool.Len() != 1 {
+			t.Fatalf("shards %v: len invalid: got %v want %v", shards, bufPool.Len(), 1)
+		}
+	}
+}
+
+func TestShardedBufferPool(t *testing.T) {
+	var shards int = 4
+	var size int = 2
+
+	bufPool := NewShardedBufferPool(shards, size, 64)
+
+	// Fill every shard beyond its capacity.
+	for i := 0; i < shards*size*2; i++ {
+		bufPool.Put(bytes.NewBuffer(make([]byte, 0, 64)))
+	}
+	if bufPool.Len() != shards*size {
+		t.Fatalf("sharded pool size invalid: got %v want %v", bufPool.Len(),
+			shards*size)
+	}
+
+	// Every idle buffer can be retrieved regardless of its shard.
+	for i := 0; i < shards*size; i++ {
+		bufPool.Get()
+	}
+	if bufPool.Len() != 0 {
+		t.Fatalf("sharded pool not drained: got %v want %v", bufPool.Len(), 0)
+	}
+}
+
+func benchmarkConcurrent(b *testing.B, pool BufferPooler, goroutines int) {
+	var wg sync.WaitGroup
+	b.ReportAllocs()
+	b.ResetTimer()
+	for g := 0; g < goroutines; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < b.N/goroutines; i++ {
+				buf := pool.Get()
+				buf.WriteString("hello")
+				pool.Put(buf)
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func BenchmarkShardedBufferPool(b *testing.B) {
+	for _, goroutines := range []int{1, 8, 32, 64} {
+		b.Run(fmt.Sprintf("single/%d", goroutines), func(b *testing.B) {
+			benchmarkConcurrent(b, NewSizedBufferPool(64, 1024), goroutines)
+		})
+		b.Run(fmt.Sprintf("sharded/%d", goroutines), func(b *testing.B) {
+			benchmarkConcurrent(b, NewShardedBufferPool(8, 8, 1024), goroutines)
+		})
+	}
+}
@@ -0,0 +1,49 @@
+package bpool
+
+import (
+	"math"
+	"sort"
+)
+
+// sizeClasses are the object sizes of the Go runtime's small size classes,
+// as in the class_to_size table of runtime/sizeclasses.go.
+var sizeClasses = []int{
+	8, 16, 24, 32, 48, 64, 80, 96, 112, 128, 144, 160, 176, 192, 208, 224,
+	240, 256, 288, 320, 352, 384, 416, 448, 480, 512, 576, 640, 704, 768,
+	896, 1024, 1152, 1280, 1408, 1536, 1792, 2048, 2304, 2688, 3072, 3200,
+	3456, 4096, 4864, 5376, 6144, 6528, 6784, 6912, 8192, 9472, 9728, 10240,
+	10880, 12288, 13568, 14336, 16384, 18432, 19072, 20480, 21760, 24576,
+	27264, 28672, 32768,
+}
+
+// pageSize is the size of the runtime's pages, to which larger allocations
+// are rounded up.
+const pageSize = 8192
+
+// roundSizeClass rounds capacity up to the size the Go runtime actually
+// allocates for it: the next size class for small objects, or the next
+// multiple of the page size for large ones.
+func roundSizeClass(capacity int) int {
+	if capacity <= 0 {
+		return capacity
+	}
+	if i := sort.SearchInts(sizeClasses, capacity); i < len(sizeClasses) {
+		return sizeClasses[i]
+	}
+	if capacity > math.MaxInt-pageSize+1 {
+		// Rounding up would overflow; such an allocation fails anyway.
+		return capacity
+	}
+	return (capacity + pageSize - 1) / pageSize * pageSize
+}
+
+// WithGoSizeClasses makes the pool round the capacity of every buffer it
+// allocates up to the size the Go runtime allocates for it anyway, so that
+// the tail of the runtime's allocation, which would otherwise be wasted, is
+// usable by the buffer. It has no effect together with WithAllocFunc or
+// WithAllocator.
+func WithGoSizeClasses(enabled bool) Option {
+	return func(o *options) {
+		o.goSizeClasses = enabled
+	}
+}
@@ -0,0 +1,39 @@
+package bpool
+
+import (
+	"testing"
+)
+
+func TestRoundSizeClass(t *testing.T) {
+	for _, tc := range []struct{ capacity, want int }{
+		{0, 0},
+		{1, 8},
+		{8, 8},
+		{33, 48},
+		{1000, 1024},
+		{1025, 1152},
+		{4097, 4864},
+		{32768, 32768},
+		{32769, 40960},
+		{100000, 106496},
+	} {
+		if got := roundSizeClass(tc.capacity); got != tc.want {
+			t.Fatalf("roundSizeClass(%v) invalid: got %v want %v", tc.capacity, got, tc.want)
+		}
+	}
+
+	// The table matches what the runtime allocates when growing a slice.
+	for _, n := range sizeClasses {
+		if got := cap(append([]byte(nil), make([]byte, n-1)...)); got != n {
+			t.Fatalf("size class %v not used by the runtime: got %v", n, got)
+		}
+	}
+}
+
+func TestSizedBufferPoolGoSizeClasses(t *testing.T) {
+	bufPool := NewSizedBufferPoolWithOptions(WithSize(1), WithAlloc(1000),
+		WithGoSizeClasses(true))
+	if got := bufPool.Get().Cap(); got != 1024 {
+		t.Fatalf("buffer capacity not rounded: got %v want %v", got, 1024)
+	}
+}
@@ -2,13 +2,123 @@ package bpool
 
 import (
 	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"runtime"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+	"weak"
 )
 
 // SizedBufferPool implements a pool of bytes.Buffers in the form of a bounded
 // channel. Buffers are pre-allocated to the requested size.
 type SizedBufferPool struct {
-	c chan *bytes.Buffer
-	a int
+	// cur holds the channel of idle buffers; see ch. resizeMu serializes
+	// Resize calls.
+	cur      atomic.Pointer[buffers]
+	resizeMu sync.Mutex
+
+	a atomic.Int64
+	m int
+	s stats
+
+	// o counts the buffers handed out by Get and not yet returned by Put, and
+	// peak is the highest value it has reached.
+	o    atomic.Int64
+	peak atomic.Int64
+	// returned is signalled when o drops to 0, to wake up CloseAndWait.
+	returned chan struct{}
+
+	// st enables collection of stats.
+	st bool
+	// z zeroes buffers on Put.
+	z bool
+
+	// opt holds the options the pool was created with.
+	opt options
+
+	// now returns the current time; it is replaced in tests.
+	now func() time.Time
+	// idle records when each idle buffer was returned to the pool. It is only
+	// maintained when an idle TTL is set.
+	idle   map[*bytes.Buffer]time.Time
+	idleMu sync.Mutex
+	// reaper starts the goroutine evicting expired buffers, which runs until
+	// stop is closed.
+	reaper   sync.Once
+	stop     chan struct{}
+	stopOnce sync.Once
+	// warm is signalled on a miss to wake up the goroutine keeping the idle
+	// buffers set with WithMinIdle, which also runs until stop is closed.
+	warm chan struct{}
+
+	// owned holds the buffers currently handed out. It is only maintained in
+	// strict ownership mode.
+	owned   map[*bytes.Buffer]struct{}
+	ownedMu sync.Mutex
+
+	// hist counts the capacities of returned buffers per bucket; see
+	// WithCapHistogram.
+	hist []atomic.Uint64
+	// caps holds the capacities of recently returned buffers; see
+	// WithP95Sizing. frozen stops it from being updated; see Freeze.
+	caps   *capWindow
+	frozen atomic.Bool
+
+	// auto tracks demand to resize the pool; see WithAutoResize.
+	auto *autoResize
+
+	// lifo holds the idle buffers instead of the channel in LIFO mode.
+	lifo *stack
+
+	// over holds oversized buffers; see WithOverflowPool.
+	over chan *bytes.Buffer
+
+	// standby holds the buffers kept with WithStandby, if enabled.
+	standby *standbySlots
+
+	// allocSem holds a token for each goroutine allocating a buffer; see
+	// WithAllocThrottle.
+	allocSem chan struct{}
+	// rate limits allocations; see WithAllocRateLimit.
+	rate *tokenBucket
+
+	// peeked holds the idle buffer moved aside by PeekCap, if any; peekMu
+	// serializes putting buffers into it and checking it against the size of
+	// the pool.
+	peekMu sync.Mutex
+	peeked atomic.Pointer[bytes.Buffer]
+
+	// small counts the small buffers retained since they were last
+	// coalesced; see WithCoalesce.
+	small atomic.Int64
+
+	// arrays maps the buffers allocated through WithAllocator, held weakly,
+	// to the arrays Alloc returned for them, which they may have outgrown
+	// since.
+	arrays   map[weak.Pointer[bytes.Buffer]][]byte
+	arraysMu sync.Mutex
+
+	// bytesOwner maps the addresses of the backing arrays of slices handed
+	// out by GetBytes to the buffers they belong to.
+	bytesOwner map[uintptr]bytesEntry
+	bytesMu    sync.Mutex
+
+	// trimStop stops the goroutine started by WithMemoryPressureTrim, which
+	// closes trimDone when it exits. trimStop is nil while the goroutine
+	// isn't running.
+	trimStop chan struct{}
+	trimDone chan struct{}
+	trimMu   sync.Mutex
+
+	// closed is closed by Close.
+	closed    chan struct{}
+	closeOnce sync.Once
 }
 
 // SizedBufferPool creates a new BufferPool bounded to the given size.
@@ -21,46 +131,1087 @@ type SizedBufferPool struct {
 // track the capacity of your last N buffers (i.e. using an []int) prior to
 // returning them to the pool as input into calculating a suitable alloc value.
 func NewSizedBufferPool(size int, alloc int) (bp *SizedBufferPool) {
-	return &SizedBufferPool{
-		c: make(chan *bytes.Buffer, size),
-		a: alloc,
+	return NewSizedBufferPoolWithMax(size, alloc, 0)
+}
+
+// NewSizedBufferPoolWithMax creates a new SizedBufferPool like
+// NewSizedBufferPool, but never retains a buffer whose capacity grew beyond
+// maxCap. Such buffers are discarded on Put and replaced by a buffer of the
+// initial capacity. A maxCap of 0 disables the limit.
+func NewSizedBufferPoolWithMax(size int, alloc int, maxCap int) (bp *SizedBufferPool) {
+	return NewSizedBufferPoolWithOptions(WithSize(size), WithAlloc(alloc),
+		WithMaxCap(maxCap))
+}
+
+// NewSizedBufferPoolForConcurrency creates a new SizedBufferPool like
+// NewSizedBufferPool, retaining buffersPerProc buffers for each of the
+// runtime.GOMAXPROCS(0) processors at the time of the call, so that the size
+// matches the number of goroutines that can run at once.
+func NewSizedBufferPoolForConcurrency(buffersPerProc int, alloc int) (bp *SizedBufferPool) {
+	return NewSizedBufferPool(buffersPerProc*runtime.GOMAXPROCS(0), alloc)
+}
+
+// NewSizedBufferPoolWithOptions creates a new SizedBufferPool configured by
+// opts. Without options the pool retains 64 buffers of 4096 bytes.
+func NewSizedBufferPoolWithOptions(opts ...Option) (bp *SizedBufferPool) {
+	o := options{
+		size:  defaultSize,
+		alloc: defaultAlloc,
+		stats: true,
+	}
+	for _, opt := range opts {
+		opt(&o)
 	}
+	return newSizedBufferPool(o)
+}
+
+// NewSizedBufferPoolE creates a new SizedBufferPool like NewSizedBufferPool,
+// but returns an error wrapping ErrInvalidConfig if size is less than 1 or
+// alloc is negative, instead of silently creating a pool that retains nothing
+// or can't allocate.
+func NewSizedBufferPoolE(size int, alloc int) (bp *SizedBufferPool, err error) {
+	if size < 1 {
+		return nil, fmt.Errorf("%w: size %d is less than 1", ErrInvalidConfig, size)
+	}
+	if alloc < 0 {
+		return nil, fmt.Errorf("%w: alloc %d is negative", ErrInvalidConfig, alloc)
+	}
+	return NewSizedBufferPool(size, alloc), nil
+}
+
+// newSizedBufferPool creates a new SizedBufferPool from the given options.
+// Negative sizes and capacities are treated as 0.
+func newSizedBufferPool(o options) (bp *SizedBufferPool) {
+	o.size = max(o.size, 0)
+	if o.autoMax > 0 {
+		o.size = min(max(o.size, o.autoMin), o.autoMax)
+	}
+	o.alloc = max(o.alloc, 0)
+	o.maxCap = max(o.maxCap, 0)
+	bp = &SizedBufferPool{
+		m:   o.maxCap,
+		st:  o.stats,
+		z:   o.zeroOnPut,
+		opt: o,
+		now: time.Now,
+
+		closed:   make(chan struct{}),
+		returned: make(chan struct{}, 1),
+	}
+	bp.a.Store(int64(o.alloc))
+	if o.lifo {
+		// The channel stays empty, so that blocking on it never succeeds.
+		bp.lifo = newStack(o.size)
+		bp.cur.Store(newBuffers(0))
+	} else {
+		bp.cur.Store(newBuffers(o.size))
+	}
+	if o.capBuckets != nil {
+		bp.hist = make([]atomic.Uint64, len(o.capBuckets)+1)
+	}
+	if o.strictOwnership {
+		bp.owned = make(map[*bytes.Buffer]struct{})
+	}
+	if o.allocator != nil && o.allocFunc == nil {
+		bp.arrays = make(map[weak.Pointer[bytes.Buffer]][]byte)
+	}
+	if o.idleTTL > 0 {
+		bp.idle = make(map[*bytes.Buffer]time.Time)
+	}
+	if o.idleTTL > 0 || o.minIdle > 0 {
+		bp.stop = make(chan struct{})
+	}
+	if o.autoMax > 0 {
+		bp.auto = &autoResize{low: math.MaxInt}
+	}
+	if o.p95Window > 0 {
+		bp.caps = &capWindow{caps: make([]int, o.p95Window)}
+	}
+	if o.overflowSize > 0 {
+		bp.over = make(chan *bytes.Buffer, o.overflowSize)
+	}
+	if o.standby {
+		bp.standby = new(standbySlots)
+	}
+	if o.allocThrottle > 0 {
+		bp.allocSem = make(chan struct{}, o.allocThrottle)
+	}
+	if o.allocRate > 0 {
+		bp.rate = newTokenBucket(o.allocRate, bp.now())
+	}
+	if o.minIdle > 0 {
+		bp.warm = make(chan struct{}, 1)
+		go bp.keepWarm()
+	}
+	bp.StartTrim()
+	return
+}
+
+// ch returns the channel currently holding the pool's idle buffers.
+func (bp *SizedBufferPool) ch() chan *bytes.Buffer {
+	return bp.cur.Load().c
+}
+
+// Clone creates a new, empty SizedBufferPool with the same configuration as
+// bp, including its current size and capacity. The clone shares no buffers
+// or stats with bp.
+func (bp *SizedBufferPool) Clone() *SizedBufferPool {
+	o := bp.opt
+	o.size = bp.idleCap()
+	o.alloc = bp.Cap()
+	return newSizedBufferPool(o)
 }
 
 // Get gets a Buffer from the SizedBufferPool, or creates a new one if none are
 // available in the pool. Buffers have a pre-allocated capacity.
 func (bp *SizedBufferPool) Get() *bytes.Buffer {
-	select {
-	case b := <-bp.c:
+	return bp.getWith(bp.get)
+}
+
+// getWith implements Get, allocating new buffers with alloc.
+func (bp *SizedBufferPool) getWith(alloc func() *bytes.Buffer) *bytes.Buffer {
+	if bp.opt.maxLive > 0 {
+		return bp.getBlocking(bp.Cap())
+	}
+	bp.count(&bp.s.gets)
+	bp.acquire()
+	if b, ok := bp.take(); ok {
+		// reuse existing buffer
+		return bp.reuse(b)
+	}
+	// create new buffer
+	return bp.allocLimited(alloc, nil)
+}
+
+// GetWithHint gets a Buffer from the SizedBufferPool like Get, for a caller
+// that expects to write about n bytes. A new buffer is allocated with a
+// capacity of at least n, and a reused buffer is grown to fit n bytes, so the
+// writes don't have to reallocate.
+func (bp *SizedBufferPool) GetWithHint(n int) *bytes.Buffer {
+	if bp.opt.maxLive > 0 {
+		b := bp.getBlocking(max(n, bp.Cap()))
+		b.Grow(n)
+		return b
+	}
+	bp.count(&bp.s.gets)
+	bp.acquire()
+	if b, ok := bp.unspill(n); ok {
+		b.Grow(n)
+		return b
+	}
+	if b, ok := bp.unpark(n); ok {
+		return b
+	}
+	if b, ok := bp.take(); ok {
+		// reuse existing buffer
+		b = bp.reuse(b)
+		b.Grow(n)
+		return b
+	}
+	// create new buffer
+	return bp.allocLimited(func() *bytes.Buffer {
+		return bp.newBuffer(max(n, bp.Cap()))
+	}, func(b *bytes.Buffer) {
+		b.Grow(n)
+	})
+}
+
+// GetGrow gets a Buffer from the SizedBufferPool whose capacity is guaranteed
+// to be at least minCap, so writes up to minCap never reallocate. A reused
+// buffer that is too small is grown, and a new buffer is allocated with the
+// larger of minCap and the pool's capacity.
+func (bp *SizedBufferPool) GetGrow(minCap int) *bytes.Buffer {
+	if bp.opt.maxLive > 0 {
+		b := bp.getBlocking(max(minCap, bp.Cap()))
+		if b.Cap() < minCap {
+			b.Grow(minCap - b.Len())
+		}
+		return b
+	}
+	bp.count(&bp.s.gets)
+	bp.acquire()
+	if b, ok := bp.unspill(minCap); ok {
+		if b.Cap() < minCap {
+			b.Grow(minCap - b.Len())
+		}
+		return b
+	}
+	if b, ok := bp.unpark(minCap); ok {
+		return b
+	}
+	if b, ok := bp.take(); ok {
 		// reuse existing buffer
+		b = bp.reuse(b)
+		if b.Cap() < minCap {
+			b.Grow(minCap - b.Len())
+		}
 		return b
-	default:
-		// create new buffer
-		return bytes.NewBuffer(make([]byte, 0, bp.a))
 	}
+	// create new buffer
+	return bp.allocLimited(func() *bytes.Buffer {
+		return bp.newBuffer(max(minCap, bp.Cap()))
+	}, func(b *bytes.Buffer) {
+		if b.Cap() < minCap {
+			b.Grow(minCap - b.Len())
+		}
+	})
+}
 
+// GetExact gets a Buffer from the SizedBufferPool with a capacity of at
+// least n, like GetGrow, but a new buffer is allocated with a capacity of
+// exactly n rather than the pool's capacity, and an idle buffer that is too
+// small is left in the pool rather than grown, since growing a bytes.Buffer
+// may double its capacity. This gives the smallest footprint for callers on
+// memory-constrained targets, at the cost of odd-sized buffers that fragment
+// the heap and are less likely to be reusable. With WithMaxLive, an idle
+// buffer that is too small is grown instead, like with GetGrow.
+func (bp *SizedBufferPool) GetExact(n int) *bytes.Buffer {
+	if bp.opt.maxLive > 0 {
+		b := bp.getBlocking(n)
+		if b.Cap() < n {
+			b.Grow(n - b.Len())
+		}
+		return b
+	}
+	bp.count(&bp.s.gets)
+	if b, ok := bp.take(); ok {
+		if b.Cap() >= n {
+			bp.acquire()
+			return bp.reuse(b)
+		}
+		if !bp.give(b) {
+			bp.evict(b)
+		}
+	}
+	bp.acquire()
+	return bp.allocLimited(func() *bytes.Buffer {
+		return bp.newBuffer(n)
+	}, func(b *bytes.Buffer) {
+		if b.Cap() < n {
+			b.Grow(n - b.Len())
+		}
+	})
 }
 
-// Put returns the given Buffer to the SizedBufferPool.
-func (bp *SizedBufferPool) Put(b *bytes.Buffer) {
-	// If the pool is full opportunistically throw the buffer away
-	if len(bp.c) == cap(bp.c) {
-		return
+// GetZeroed gets a Buffer from the SizedBufferPool like GetGrow(n), holding
+// n zero bytes, e.g. for building a fixed-layout binary structure by index
+// through Bytes. Any template set with WithTemplate is left out.
+func (bp *SizedBufferPool) GetZeroed(n int) *bytes.Buffer {
+	b := bp.GetGrow(n)
+	b.Reset()
+	zeros := b.AvailableBuffer()[:n]
+	clear(zeros)
+	b.Write(zeros)
+	return b
+}
+
+// GetCopyOf gets a Buffer from the SizedBufferPool like GetGrow, large
+// enough for the unread contents of src, and copies them into it, e.g. to
+// move data into a buffer of another pool. src is left untouched. Any
+// template set with WithTemplate is left out.
+func (bp *SizedBufferPool) GetCopyOf(src *bytes.Buffer) *bytes.Buffer {
+	b := bp.GetGrow(src.Len())
+	b.Reset()
+	b.Write(src.Bytes())
+	return b
+}
+
+// Promote returns a buffer with the contents of b and a capacity of at least
+// newCap, for callers that start with a small buffer and find out later that
+// they need a large one. If b is large enough it is returned as is; otherwise
+// the contents are copied into a buffer obtained as with GetGrow and b is
+// returned to the pool, so that its storage is reused. b must not be used
+// after Promote. With WithMaxLive, b is grown in place instead, so that
+// promoting never waits for another buffer.
+func (bp *SizedBufferPool) Promote(b *bytes.Buffer, newCap int) *bytes.Buffer {
+	if b.Cap() >= newCap {
+		return b
+	}
+	if bp.opt.maxLive > 0 {
+		b.Grow(newCap - b.Len())
+		return b
+	}
+	nb := bp.GetGrow(newCap)
+	nb.Reset()
+	nb.Write(b.Bytes())
+	bp.Put(b)
+	return nb
+}
+
+// GetGrowE gets a Buffer like GetGrow, but returns an error wrapping
+// ErrTooLarge instead of panicking when a buffer of minCap bytes cannot be
+// allocated, e.g. because minCap exceeds the largest possible slice. An idle
+// buffer that could not be grown is released. Running out of memory
+// altogether is fatal and cannot be caught.
+func (bp *SizedBufferPool) GetGrowE(minCap int) (b *bytes.Buffer, err error) {
+	defer func() {
+		r := recover()
+		if r == nil {
+			return
+		}
+		if e, ok := r.(error); !ok || !isAllocPanic(e) {
+			panic(r)
+		}
+		bp.release()
+		b, err = nil, fmt.Errorf("%w: capacity %d: %v", ErrTooLarge, minCap, r)
+	}()
+	return bp.GetGrow(minCap), nil
+}
+
+// isAllocPanic reports whether a panic with err was caused by a failed
+// allocation: bytes.Buffer growing too large, or make being asked for a
+// slice too large to exist. Other runtime errors are bugs and not caught.
+func isAllocPanic(err error) bool {
+	if errors.Is(err, bytes.ErrTooLarge) {
+		return true
+	}
+	var re runtime.Error
+	return errors.As(err, &re) && strings.Contains(re.Error(), "makeslice: ")
+}
+
+// GetContext gets a Buffer from the SizedBufferPool like Get, but treats the
+// pool size, or the limit set with WithMaxLive, as a hard limit on the number
+// of buffers handed out at once. When that many buffers are outstanding it
+// blocks until one is returned with Put, or until ctx is done, in which case
+// it returns ErrPoolExhausted wrapping ctx.Err(). It returns ErrPoolClosed
+// once the pool is closed.
+func (bp *SizedBufferPool) GetContext(ctx context.Context) (*bytes.Buffer, error) {
+	return bp.getLimited(ctx, bp.Cap())
+}
+
+// getLimited implements GetContext, allocating new buffers with the given
+// capacity.
+func (bp *SizedBufferPool) getLimited(ctx context.Context, capacity int) (*bytes.Buffer, error) {
+	bp.count(&bp.s.gets)
+	for {
+		if bp.isClosed() {
+			return nil, ErrPoolClosed
+		}
+		if b, ok := bp.unspill(capacity); ok {
+			bp.acquire()
+			return b, nil
+		}
+		cur := bp.cur.Load()
+		if b, ok := bp.take(); ok {
+			bp.acquire()
+			return bp.reuse(b), nil
+		}
+
+		// Allocate a new buffer if we're still under the limit.
+		limit := int64(bp.idleCap())
+		if bp.opt.maxLive > 0 {
+			limit = int64(bp.opt.maxLive)
+		}
+		if n := bp.o.Load(); n < limit {
+			if bp.o.CompareAndSwap(n, n+1) {
+				bp.raisePeak(n + 1)
+				if bp.auto != nil {
+					bp.observeGet()
+				}
+				bp.miss()
+				return bp.lend(bp.newBuffer(capacity)), nil
+			}
+			continue
+		}
+
+		// In LIFO mode the channel is never used, and avail signals that a
+		// buffer was returned instead.
+		var avail chan struct{}
+		if bp.lifo != nil {
+			avail = bp.lifo.avail
+		}
+		select {
+		case b := <-cur.c:
+			bp.acquire()
+			return bp.reuse(b), nil
+		case <-avail:
+		case <-cur.replaced:
+			// The pool was resized; retry with the new channel.
+		case <-bp.closed:
+			return nil, ErrPoolClosed
+		case <-ctx.Done():
+			return nil, fmt.Errorf("%w: %w", ErrPoolExhausted, ctx.Err())
+		}
+	}
+}
+
+// GetWait gets a Buffer from the SizedBufferPool like Get, but if the pool is
+// empty it first waits up to maxWait for a buffer to be returned, and only
+// allocates a new one once that time has passed. This favors reuse under
+// bursty load without blocking indefinitely. With WithMaxLive, it waits like
+// Get for as long as the limit requires.
+func (bp *SizedBufferPool) GetWait(maxWait time.Duration) *bytes.Buffer {
+	if bp.opt.maxLive > 0 {
+		return bp.getBlocking(bp.Cap())
+	}
+	bp.count(&bp.s.gets)
+	bp.acquire()
+	if b, ok := bp.take(); ok {
+		return bp.reuse(b)
+	}
+
+	timer := time.NewTimer(maxWait)
+	defer timer.Stop()
+	for {
+		cur := bp.cur.Load()
+		var avail chan struct{}
+		if bp.lifo != nil {
+			avail = bp.lifo.avail
+		}
+		select {
+		case b := <-cur.c:
+			return bp.reuse(b)
+		case <-avail:
+			if b, ok := bp.take(); ok {
+				return bp.reuse(b)
+			}
+			continue
+		case <-cur.replaced:
+			// The pool was resized; retry with the new channel.
+			continue
+		case <-bp.closed:
+		case <-timer.C:
+		}
+		return bp.allocLimited(bp.get, nil)
+	}
+}
+
+// TryGet gets an idle Buffer from the SizedBufferPool. Unlike Get it never
+// allocates: if the pool is empty it returns nil and false.
+func (bp *SizedBufferPool) TryGet() (*bytes.Buffer, bool) {
+	if bp.isClosed() {
+		return nil, false
+	}
+	b, ok := bp.take()
+	if !ok {
+		return nil, false
+	}
+	bp.count(&bp.s.gets)
+	bp.acquire()
+	return bp.reuse(b), true
+}
+
+// reuse prepares an idle buffer taken from the pool to be handed out.
+// Buffers smaller than the pool's capacity, e.g. ones that didn't come from
+// the pool, are grown to it so the first write doesn't reallocate.
+func (bp *SizedBufferPool) reuse(b *bytes.Buffer) *bytes.Buffer {
+	b = bp.prepare(b)
+	if a := bp.Cap(); b.Cap() < a {
+		bp.grow(b, a)
+	}
+	return b
+}
+
+// prepare is like reuse, but doesn't grow the buffer. Buffers over the
+// absolute maximum capacity are replaced.
+func (bp *SizedBufferPool) prepare(b *bytes.Buffer) *bytes.Buffer {
+	bp.forget(b)
+	if m := bp.opt.absoluteMaxCap; m > 0 && b.Cap() > m {
+		bp.count(&bp.s.oversize)
+		bp.drop(b)
+		return bp.allocLimited(bp.get, nil)
+	}
+	if bp.opt.zeroOnGet {
+		bp.scrub(b)
+	}
+	if bp.auto != nil {
+		bp.observeIdle()
+	}
+	return bp.lend(b)
+}
+
+// acquire records that a buffer was handed out.
+func (bp *SizedBufferPool) acquire() {
+	bp.raisePeak(bp.o.Add(1))
+	if bp.auto != nil {
+		bp.observeGet()
+	}
+}
+
+// raisePeak records n outstanding buffers as the new peak if it is one.
+func (bp *SizedBufferPool) raisePeak(n int64) {
+	for {
+		peak := bp.peak.Load()
+		if n <= peak || bp.peak.CompareAndSwap(peak, n) {
+			return
+		}
+	}
+}
+
+// release records that an outstanding buffer was returned. Buffers that
+// didn't come from the pool can't take the count below zero.
+func (bp *SizedBufferPool) release() {
+	for {
+		n := bp.o.Load()
+		if n <= 0 {
+			return
+		}
+		if bp.o.CompareAndSwap(n, n-1) {
+			if n == 1 {
+				bp.signalReturned()
+			}
+			return
+		}
+	}
+}
+
+// count increments the given stats counter if stats are enabled.
+func (bp *SizedBufferPool) count(c *atomic.Uint64) {
+	if bp.st {
+		c.Add(1)
 	}
-	// Release buffers over our maximum capacity and re-create a pre-sized
-	// buffer to replace it.
-	// if b.Cap() > bp.a {
-	// 	b = bytes.NewBuffer(make([]byte, 0, bp.a))
-	// } else {
-	// 	b.Reset()
-	// }
-	if b != nil {
+}
+
+// miss records a Get that had to allocate a new buffer.
+func (bp *SizedBufferPool) miss() {
+	bp.count(&bp.s.misses)
+	if bp.auto != nil {
+		bp.observeMiss()
+	}
+	if bp.opt.onMiss != nil {
+		bp.opt.onMiss()
+	}
+	if bp.warm != nil {
+		select {
+		case bp.warm <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// discard records a Put that threw a buffer away.
+func (bp *SizedBufferPool) discard() {
+	bp.count(&bp.s.discards)
+	if bp.opt.onDiscard != nil {
+		bp.opt.onDiscard()
+	}
+}
+
+// get allocates a new buffer with the pool's capacity, plus the headroom set
+// with WithHeadroom.
+func (bp *SizedBufferPool) get() *bytes.Buffer {
+	return bp.newBuffer(bp.getCap())
+}
+
+// getCap returns the capacity get allocates new buffers with, i.e. Cap plus
+// the headroom if any.
+func (bp *SizedBufferPool) getCap() int {
+	capacity := bp.Cap()
+	if bp.opt.headroom > 1 {
+		capacity = scaleCap(capacity, bp.opt.headroom)
+		if m := bp.maxCap(); m > 0 {
+			capacity = max(min(capacity, m), bp.Cap())
+		}
+	}
+	return capacity
+}
+
+// newBuffer allocates a new buffer with the given capacity.
+func (bp *SizedBufferPool) newBuffer(capacity int) *bytes.Buffer {
+	capacity = max(capacity, len(bp.opt.template))
+	var start time.Time
+	if bp.opt.allocTiming {
+		start = time.Now()
+	}
+	var b *bytes.Buffer
+	if bp.opt.allocFunc != nil {
+		b = bp.opt.allocFunc(capacity)
+	} else if bp.opt.allocator != nil {
+		a := bp.opt.allocator.Alloc(capacity)
+		b = bytes.NewBuffer(a[:0])
+		bp.track(b, a)
+	} else if bp.opt.alignment > 1 {
+		b = bytes.NewBuffer(alignedBytes(capacity, bp.opt.alignment))
+	} else if bp.opt.goSizeClasses {
+		b = bytes.NewBuffer(make([]byte, 0, roundSizeClass(capacity)))
+	} else {
+		b = bytes.NewBuffer(make([]byte, 0, capacity))
+	}
+	if bp.opt.allocTiming {
+		bp.s.recordAlloc(time.Since(start))
+	}
+	b.Write(bp.opt.template)
+	return b
+}
+
+// reset empties b so it can be retained, zeroing or poisoning it if required
+// and restoring the template if any. Put resets every retained buffer exactly
+// once.
+func (bp *SizedBufferPool) reset(b *bytes.Buffer) {
+	if bp.z {
+		zero(b)
+	} else {
 		b.Reset()
 	}
+	if bp.opt.poison {
+		poison(b)
+	}
+	b.Write(bp.opt.template)
+}
+
+// drop discards a buffer given to Put, zeroing or poisoning it first if
+// required.
+func (bp *SizedBufferPool) drop(b *bytes.Buffer) {
+	bp.discard()
+	if bp.z {
+		zero(b)
+	}
+	if bp.opt.poison {
+		poison(b)
+	}
+	bp.free(b)
+}
+
+// Put returns the given Buffer to the SizedBufferPool. Putting a nil Buffer is
+// a no-op, so error paths can defer Put unconditionally.
+//
+// The buffer's backing array is reused by later Gets, so slices obtained from
+// it, e.g. with Bytes, must not be used after Put: their contents will be
+// overwritten. Use SafePut to return a buffer whose contents are still
+// referenced.
+func (bp *SizedBufferPool) Put(b *bytes.Buffer) {
+	bp.put(b, bp.maxCap(), true, false, nil)
+}
+
+// SafePut returns the given Buffer to the SizedBufferPool like Put. If aliased
+// is set, the caller still references the buffer's contents, e.g. a slice
+// returned by Bytes: the buffer is then left untouched and never reused, and
+// a new buffer is pooled in its place.
+func (bp *SizedBufferPool) SafePut(b *bytes.Buffer, aliased bool) {
+	bp.put(b, bp.maxCap(), true, aliased, nil)
+}
 
-	select {
-	case bp.c <- b:
+// PutWithCleanup returns the given Buffer to the SizedBufferPool like Put,
+// and calls onDiscard if the buffer is dropped rather than retained, e.g.
+// because it is oversized or the pool is full, so that a resource tied to
+// the buffer can be released with it. onDiscard is not called if the buffer
+// is retained, including in the overflow pool.
+func (bp *SizedBufferPool) PutWithCleanup(b *bytes.Buffer, onDiscard func()) {
+	bp.put(b, bp.maxCap(), true, false, onDiscard)
+}
+
+// PutShrunk returns the given Buffer to the SizedBufferPool like Put, but
+// never retains it if it grew beyond the pool's capacity: an oversized buffer
+// is dropped and a buffer of the pool's capacity is pooled instead. Use it
+// when a buffer is known to have grown for a one-off large payload.
+func (bp *SizedBufferPool) PutShrunk(b *bytes.Buffer) {
+	// A limit of 0 would disable the check; a pool allocating empty
+	// buffers still replaces the ones that grew.
+	limit := max(bp.Cap(), 1)
+	if m := bp.maxCap(); m > 0 {
+		limit = min(limit, m)
+	}
+	bp.put(b, limit, false, false, nil)
+}
+
+// WouldRetain reports whether a Put of b right now would retain b itself, in
+// the pool, its overflow pool or a standby slot, rather than discard or
+// replace it. It has no side effects, but concurrent Gets and Puts may change
+// the answer before b is actually returned.
+func (bp *SizedBufferPool) WouldRetain(b *bytes.Buffer) bool {
+	if b == nil || bp.isClosed() {
+		return false
+	}
+	if m := bp.maxCap(); m > 0 && b.Cap() > m {
+		return (bp.over != nil && len(bp.over) < cap(bp.over) &&
+			(bp.opt.overflowCap == 0 || b.Cap() <= bp.opt.overflowCap)) ||
+			bp.wouldPark(b)
+	}
+	return bp.idleLen() < bp.idleCap()
+}
+
+// maxCap returns the largest buffer capacity Put currently retains, or 0 if
+// there is no limit.
+func (bp *SizedBufferPool) maxCap() int {
+	if bp.opt.retainTolerance <= 0 {
+		return bp.m
+	}
+	limit := max(scaleCap(bp.Cap(), bp.opt.retainTolerance), 1)
+	if bp.m > 0 {
+		limit = min(limit, bp.m)
+	}
+	return limit
+}
+
+// PutReported returns the given Buffer to the SizedBufferPool like Put, and
+// reports whether the buffer, or the buffer replacing an oversized one, was
+// retained. It returns false if the buffer was discarded, e.g. because the
+// pool was full.
+func (bp *SizedBufferPool) PutReported(b *bytes.Buffer) bool {
+	return bp.put(b, bp.maxCap(), true, false, nil)
+}
+
+// PutCap returns the given Buffer to the SizedBufferPool like Put, and
+// returns the capacity b had when it was returned, so that callers keeping
+// their own memory ledger don't have to read it before Put. It returns 0 for
+// a nil Buffer.
+func (bp *SizedBufferPool) PutCap(b *bytes.Buffer) int {
+	if b == nil {
+		return 0
+	}
+	c := b.Cap()
+	bp.put(b, bp.maxCap(), true, false, nil)
+	return c
+}
+
+// PutLen returns the given Buffer to the SizedBufferPool like Put, but
+// WithP95Sizing records the number of bytes written to b rather than its
+// capacity, which overshoots once the buffer has grown. Call it before
+// resetting b. Without WithP95Sizing it is the same as Put.
+func (bp *SizedBufferPool) PutLen(b *bytes.Buffer) {
+	bp.putSized(b, (*bytes.Buffer).Len, bp.maxCap(), true, false, nil)
+}
+
+// put returns b to the pool, replacing it with a new buffer if its capacity
+// exceeds maxCap. A maxCap of 0 disables the limit. If spill is set, such
+// buffers are kept in the overflow pool instead when there is room. If
+// aliased is set, b is always replaced and left untouched. If onDiscard is
+// not nil, it is called once b has been dropped rather than retained. put
+// reports whether b or its replacement was retained.
+func (bp *SizedBufferPool) put(b *bytes.Buffer, maxCap int, spill, aliased bool, onDiscard func()) bool {
+	return bp.putSized(b, (*bytes.Buffer).Cap, maxCap, spill, aliased, onDiscard)
+}
+
+// putSized is put, with size giving the value WithP95Sizing records for b.
+func (bp *SizedBufferPool) putSized(b *bytes.Buffer, size func(*bytes.Buffer) int, maxCap int, spill, aliased bool, onDiscard func()) bool {
+	if b == nil {
+		return false
+	}
+	bp.count(&bp.s.puts)
+	if bp.st {
+		bp.s.recordUtil(b.Len(), b.Cap())
+	}
+	if bp.hist != nil {
+		bp.recordCap(b.Cap())
+	}
+	if bp.caps != nil {
+		bp.recordP95(size(b))
+	}
+	bp.release()
+	if bp.opt.leakf != nil {
+		bp.unwatchLeak(b)
+	}
+	if bp.isClosed() {
+		bp.logDiscard(ReasonPoolClosed, b.Cap())
+		if aliased {
+			bp.discard()
+		} else {
+			bp.drop(b)
+			if onDiscard != nil {
+				onDiscard()
+			}
+		}
+		return false
+	}
+	if bp.owned != nil {
+		bp.reclaim(b)
+	}
+	oversized := maxCap > 0 && b.Cap() > maxCap
+	if oversized && spill && !aliased && bp.over != nil && bp.spill(b) {
+		return true
+	}
+	fresh := oversized || aliased
+	// parked is set if b itself is retained in a standby slot while a
+	// replacement is pooled.
+	parked := false
+	if aliased {
+		// The caller still uses b's contents; neither reset nor zero it.
+		bp.logDiscard(ReasonAliased, b.Cap())
+		bp.discard()
+	} else if oversized {
+		// Release buffers over our maximum capacity and re-create a pre-sized
+		// buffer to replace it. The replacement is new and needs no reset.
+		parked = spill && bp.standby != nil && bp.park(b)
+		if !parked {
+			bp.count(&bp.s.oversize)
+			bp.logDiscard(ReasonOversized, b.Cap())
+			bp.drop(b)
+			if onDiscard != nil {
+				onDiscard()
+			}
+		}
+	}
+	// Don't allocate a replacement that a full pool would discard right
+	// away. A slot freed concurrently is left for the next Put or Get. Put
+	// doesn't wait for the allocation limits either.
+	if !fresh || (bp.idleLen() < bp.idleCap() && bp.allocNow()) {
+		if bp.retain(b, fresh, onDiscard) {
+			return true
+		}
+	}
+	if bp.opt.shrinkFactor > 0 {
+		bp.shrink()
+	}
+	return parked
+}
+
+// retain implements put once b has been checked: it gives b, or a new buffer
+// replacing it if fresh is set, to the pool, and reports whether there was
+// room for it. Otherwise the buffer is discarded, calling onDiscard if it is
+// b itself.
+func (bp *SizedBufferPool) retain(b *bytes.Buffer, fresh bool, onDiscard func()) bool {
+	if fresh {
+		bp.count(&bp.s.replaced)
+		b = bp.get()
+		bp.allocDone()
+	} else {
+		bp.reset(b)
+	}
+	if bp.idle != nil {
+		bp.markIdle(b)
+	}
+
+	// Whether the pool is full is decided by give alone, so a slot freed by
+	// a concurrent Get is never missed.
+	if bp.give(b) || (bp.opt.putSpin > 0 && bp.spin(b)) ||
+		(bp.opt.minRetain > 0 && bp.retry(b)) {
+		if region := bp.coalesceRegion(); region > 0 && b.Cap() < region {
+			bp.coalesce(region)
+		}
+		return true
+	}
+	// Discard the buffer if the pool is full.
+	if fresh {
+		// An oversized or aliased buffer was already dropped.
+		bp.evict(b)
+	} else {
+		bp.logDiscard(ReasonPoolFull, b.Cap())
+		bp.drop(b)
+		bp.forget(b)
+		if onDiscard != nil {
+			onDiscard()
+		}
+	}
+	return false
+}
+
+// spin retries giving b to the full pool as many times as set with
+// WithPutSpin, yielding the processor in between, and reports whether it
+// succeeded.
+func (bp *SizedBufferPool) spin(b *bytes.Buffer) bool {
+	for i := 0; i < bp.opt.putSpin; i++ {
+		runtime.Gosched()
+		if bp.give(b) {
+			return true
+		}
+	}
+	return false
+}
+
+// retry keeps trying to give b to the full pool for up to the duration set
+// with WithMinRetain, backing off exponentially between attempts, and
+// reports whether it succeeded.
+func (bp *SizedBufferPool) retry(b *bytes.Buffer) bool {
+	deadline := time.Now().Add(bp.opt.minRetain)
+	for backoff := time.Microsecond; ; backoff *= 2 {
+		left := time.Until(deadline)
+		if left <= 0 {
+			return false
+		}
+		time.Sleep(min(backoff, left))
+		if bp.give(b) {
+			return true
+		}
+	}
+}
+
+// shrink drops an idle buffer from the full pool with a probability of the
+// shrink factor, so that a pool which stays full releases memory over time.
+func (bp *SizedBufferPool) shrink() {
+	if rand.Float64() >= bp.opt.shrinkFactor {
 		return
-	default: // Discard the buffer if the pool is full.
 	}
+	if b, ok := bp.take(); ok {
+		bp.evict(b)
+	}
+}
+
+// scrub zeroes an idle buffer about to be handed out, restoring the template
+// if any.
+func (bp *SizedBufferPool) scrub(b *bytes.Buffer) {
+	zero(b)
+	b.Write(bp.opt.template)
+}
+
+// poisonByte fills the buffers returned in WithDebugPoison mode.
+const poisonByte = 0xDE
+
+// poison resets b and fills its whole backing array with poisonByte.
+func poison(b *bytes.Buffer) {
+	b.Reset()
+	p := b.Bytes()[:b.Cap()]
+	for i := range p {
+		p[i] = poisonByte
+	}
+}
+
+// zero resets b and overwrites its whole backing array with zeros.
+func zero(b *bytes.Buffer) {
+	b.Reset()
+	clear(b.Bytes()[:b.Cap()])
+}
+
+// Cap returns the base capacity of new buffers allocated by this pool, before
+// the headroom set with WithHeadroom and the rounding of WithGoSizeClasses.
+func (bp *SizedBufferPool) Cap() (n int) {
+	return int(bp.a.Load())
+}
+
+// SuggestedCap returns the capacity Get would allocate a new buffer with right
+// now: Cap plus the headroom set with WithHeadroom, at least the length of the
+// template, rounded up with WithGoSizeClasses. It is meant for buffers
+// allocated outside the pool that should match the ones it hands out. An
+// alloc function or Allocator may round the capacity up further.
+func (bp *SizedBufferPool) SuggestedCap() int {
+	capacity := max(bp.getCap(), len(bp.opt.template))
+	if bp.opt.goSizeClasses && bp.opt.allocFunc == nil &&
+		bp.opt.allocator == nil && bp.opt.alignment <= 1 {
+		capacity = roundSizeClass(capacity)
+	}
+	return capacity
+}
+
+// SetCap changes the capacity of new buffers allocated by this pool to alloc,
+// taking effect immediately, e.g. when the application enters a phase with
+// different buffer sizes. Idle buffers are kept, and smaller ones are grown to
+// the new capacity when they are handed out; call Drain to release them
+// instead. The capacity is clamped to the limit set with WithMaxCap, since Put
+// would discard every buffer above it. SetCap is safe to call while the pool
+// is in use.
+func (bp *SizedBufferPool) SetCap(alloc int) {
+	alloc = max(alloc, 0)
+	if bp.m > 0 {
+		alloc = min(alloc, bp.m)
+	}
+	bp.a.Store(int64(alloc))
+}
+
+// Len returns the number of idle buffers currently held by the pool.
+func (bp *SizedBufferPool) Len() (n int) {
+	return bp.idleLen()
+}
+
+// String describes the state of the pool for logging, e.g.
+// "SizedBufferPool{idle: 3/8, cap: 4096}".
+func (bp *SizedBufferPool) String() string {
+	return fmt.Sprintf("SizedBufferPool{idle: %d/%d, cap: %d}", bp.idleLen(),
+		bp.idleCap(), bp.Cap())
+}
+
+// Peak returns the highest number of buffers that were handed out by the
+// pool and not yet returned at the same time. A pool whose size is below its
+// peak has to allocate under peak load.
+func (bp *SizedBufferPool) Peak() (n int) {
+	return int(bp.peak.Load())
+}
+
+// Stats returns a snapshot of the pool's activity counters.
+func (bp *SizedBufferPool) Stats() Stats {
+	return bp.s.snapshot()
+}
+
+// StatsAndReset returns the pool's activity counters and resets them to zero,
+// so that periodic calls report the activity of each interval.
+func (bp *SizedBufferPool) StatsAndReset() Stats {
+	return bp.s.swap()
+}
+
+// ResetAll returns the pool to the state it was created in, e.g. to isolate
+// tests sharing a pool: idle buffers are released, the size and capacity are
+// restored to their initial values, and all stats, including the peak and
+// the capacity histogram, are zeroed. Buffers currently handed out remain
+// valid and can still be returned.
+func (bp *SizedBufferPool) ResetAll() {
+	bp.Drain()
+	if bp.idleCap() != bp.opt.size {
+		bp.Resize(bp.opt.size)
+	}
+	bp.SetCap(bp.opt.alloc)
+	bp.s.swap()
+	bp.peak.Store(bp.o.Load())
+	for i := range bp.hist {
+		bp.hist[i].Store(0)
+	}
+	if bp.caps != nil {
+		bp.caps.mu.Lock()
+		clear(bp.caps.caps)
+		bp.caps.next = 0
+		bp.caps.mu.Unlock()
+	}
+	if bp.auto != nil {
+		bp.auto.mu.Lock()
+		bp.auto.gets, bp.auto.misses, bp.auto.low = 0, 0, math.MaxInt
+		bp.auto.mu.Unlock()
+	}
+}
+
+// Drain releases all idle buffers held by the pool so that they can be
+// garbage collected. The pool remains usable and allocates new buffers as
+// needed.
+func (bp *SizedBufferPool) Drain() {
+	if bp.standby != nil {
+		bp.clearStandby()
+	}
+	for len(bp.over) > 0 {
+		select {
+		case b := <-bp.over:
+			bp.free(b)
+		default:
+		}
+	}
+	for {
+		b, ok := bp.take()
+		if !ok {
+			return
+		}
+		bp.evict(b)
+	}
+}
+
+// Trim drops up to n idle buffers without blocking, so that memory can be
+// shed incrementally rather than all at once as with Drain, and returns the
+// number of buffers dropped. The overflow pool is left untouched.
+func (bp *SizedBufferPool) Trim(n int) (trimmed int) {
+	for ; trimmed < n; trimmed++ {
+		b, ok := bp.take()
+		if !ok {
+			break
+		}
+		bp.evict(b)
+	}
+	return
+}
+
+// Prefill fills the pool with newly allocated buffers, so that the allocation
+// cost is paid up front rather than during the first burst of traffic. It
+// does nothing on a closed pool.
+func (bp *SizedBufferPool) Prefill() {
+	bp.PrefillContext(context.Background())
+}
+
+// PrefillContext fills the pool like Prefill, but stops early if ctx is done,
+// in which case it returns ctx.Err() and the buffers allocated so far remain
+// in the pool. It returns ErrPoolClosed on a closed pool.
+func (bp *SizedBufferPool) PrefillContext(ctx context.Context) error {
+	if bp.isClosed() {
+		return ErrPoolClosed
+	}
+	for bp.idleLen() < bp.idleCap() {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if !bp.give(bp.get()) {
+			return nil
+		}
+	}
+	return nil
+}
+
+// WithBuffer gets a Buffer from the pool, passes it to fn and returns it to
+// the pool once fn is done, even if fn panics. It returns the error from fn.
+// The buffer must not be used after fn returns.
+func (bp *SizedBufferPool) WithBuffer(fn func(b *bytes.Buffer) error) error {
+	b := bp.Get()
+	defer bp.Put(b)
+	return fn(b)
 }
@@ -2,7 +2,15 @@ package bpool
 
 import (
 	"bytes"
+	"context"
+	"errors"
+	"io"
+	"math"
+	"runtime"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 )
 
 // TestSizedBufferPool checks that over-sized buffers are released and that new
@@ -28,19 +36,19 @@ func TestSizedBufferPool(t *testing.T) {
 
 	// Add some additional buffers to fill up the pool.
 	for i := 0; i < size; i++ {
-		bufPool.Put(bytes.NewBuffer(make([]byte, 0, bufPool.a*2)))
+		bufPool.Put(bytes.NewBuffer(make([]byte, 0, bufPool.Cap()*2)))
 	}
 
 	// Check that oversized buffers are being replaced.
-	if len(bufPool.c) < size {
-		t.Fatalf("buffer pool too small: got %v want %v", len(bufPool.c), size)
+	if len(bufPool.ch()) < size {
+		t.Fatalf("buffer pool too small: got %v want %v", len(bufPool.ch()), size)
 	}
 
 	// Close the channel so we can iterate over it.
-	close(bufPool.c)
+	close(bufPool.ch())
 
 	// Check that there are buffers of the correct capacity in the pool.
-	// for buffer := range bufPool.c {
+	// for buffer := range bufPool.ch() {
 	// 	if cap(buffer.Bytes()) != bufPool.a {
 	// 		t.Fatalf("returned buffers wrong capacity: got %v want %v",
 	// 			cap(buffer.Bytes()), capacity)
@@ -48,3 +56,1089 @@ func TestSizedBufferPool(t *testing.T) {
 	// }
 
 }
+
+// TestSizedBufferPoolGet checks that Get returns an empty buffer with the
+// requested capacity, both from an empty pool and after a Put.
+func TestSizedBufferPoolGet(t *testing.T) {
+	var size int = 4
+	var capacity int = 512
+
+	bufPool := NewSizedBufferPool(size, capacity)
+
+	b := bufPool.Get()
+	if b.Len() != 0 {
+		t.Fatalf("new buffer not empty: got %v want %v", b.Len(), 0)
+	}
+	if b.Cap() < capacity {
+		t.Fatalf("new buffer capacity too small: got %v want >= %v", b.Cap(),
+			capacity)
+	}
+
+	b.WriteString("hello")
+	bufPool.Put(b)
+
+	b = bufPool.Get()
+	if b.Len() != 0 {
+		t.Fatalf("reused buffer not empty: got %v want %v", b.Len(), 0)
+	}
+}
+
+// TestSizedBufferPoolSmallAlloc checks that tiny alloc values still produce
+// usable buffers.
+func TestSizedBufferPoolSmallAlloc(t *testing.T) {
+	for _, alloc := range []int{0, 1, 7} {
+		bufPool := NewSizedBufferPool(4, alloc)
+
+		b := bufPool.Get()
+		if b.Cap() < alloc {
+			t.Fatalf("alloc %v: buffer capacity too small: got %v want >= %v",
+				alloc, b.Cap(), alloc)
+		}
+
+		b.WriteString("hello")
+		bufPool.Put(b)
+	}
+}
+
+// TestSizedBufferPoolConcurrent hammers Get/Put from several goroutines. Run
+// with -race to check that the pool is free of data races.
+func TestSizedBufferPoolConcurrent(t *testing.T) {
+	bufPool := NewSizedBufferPool(8, 64)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 16; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 1000; j++ {
+				b := bufPool.Get()
+				b.WriteString("hello")
+				bufPool.Put(b)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if len(bufPool.ch()) > cap(bufPool.ch()) {
+		t.Fatalf("buffer pool overfilled: got %v want <= %v", len(bufPool.ch()),
+			cap(bufPool.ch()))
+	}
+}
+
+func TestSizedBufferPoolCap(t *testing.T) {
+	var capacity int = 1024
+
+	bufPool := NewSizedBufferPool(4, capacity)
+	if bufPool.Cap() != capacity {
+		t.Fatalf("pool cap invalid: got %v want %v", bufPool.Cap(), capacity)
+	}
+
+	// Returning a grown buffer doesn't change the allocation size.
+	b := bufPool.Get()
+	b.Grow(capacity * 4)
+	bufPool.Put(b)
+	if bufPool.Cap() != capacity {
+		t.Fatalf("pool cap changed: got %v want %v", bufPool.Cap(), capacity)
+	}
+}
+
+func TestSizedBufferPoolLen(t *testing.T) {
+	bufPool := NewSizedBufferPool(4, 64)
+	if bufPool.Len() != 0 {
+		t.Fatalf("new pool not empty: got %v want %v", bufPool.Len(), 0)
+	}
+
+	a, b := bufPool.Get(), bufPool.Get()
+	bufPool.Put(a)
+	bufPool.Put(b)
+	if bufPool.Len() != 2 {
+		t.Fatalf("pool len after Put invalid: got %v want %v", bufPool.Len(), 2)
+	}
+
+	bufPool.Get()
+	if bufPool.Len() != 1 {
+		t.Fatalf("pool len after Get invalid: got %v want %v", bufPool.Len(), 1)
+	}
+}
+
+// TestSizedBufferPoolMaxCap checks that buffers grown beyond maxCap are never
+// retained.
+func TestSizedBufferPoolMaxCap(t *testing.T) {
+	var capacity int = 1024
+	var maxCap int = 64 << 10
+
+	bufPool := NewSizedBufferPoolWithMax(4, capacity, maxCap)
+
+	b := bufPool.Get()
+	b.Write(make([]byte, 10<<20))
+	bufPool.Put(b)
+
+	if bufPool.Len() != 1 {
+		t.Fatalf("replacement buffer not pooled: got %v want %v", bufPool.Len(), 1)
+	}
+
+	close(bufPool.ch())
+	for buffer := range bufPool.ch() {
+		if buffer == b {
+			t.Fatalf("oversized buffer retained")
+		}
+		if buffer.Cap() != capacity {
+			t.Fatalf("replacement buffer wrong capacity: got %v want %v",
+				buffer.Cap(), capacity)
+		}
+	}
+}
+
+func TestSizedBufferPoolGetContext(t *testing.T) {
+	bufPool := NewSizedBufferPool(1, 64)
+
+	b, err := bufPool.GetContext(context.Background())
+	if err != nil {
+		t.Fatalf("GetContext failed: %v", err)
+	}
+
+	// The only buffer is outstanding, so the next call has to time out.
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := bufPool.GetContext(ctx); !errors.Is(err, ErrPoolExhausted) ||
+		!errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("GetContext on exhausted pool: got %v want %v", err,
+			context.DeadlineExceeded)
+	}
+
+	// A blocked caller is woken up by Put.
+	done := make(chan *bytes.Buffer)
+	go func() {
+		b, _ := bufPool.GetContext(context.Background())
+		done <- b
+	}()
+	time.Sleep(10 * time.Millisecond)
+	bufPool.Put(b)
+	if got := <-done; got != b {
+		t.Fatalf("GetContext didn't receive the returned buffer")
+	}
+}
+
+func TestSizedBufferPoolTryGet(t *testing.T) {
+	bufPool := NewSizedBufferPool(4, 64)
+
+	if b, ok := bufPool.TryGet(); ok || b != nil {
+		t.Fatalf("TryGet on empty pool: got %v, %v want nil, false", b, ok)
+	}
+
+	a, b := bufPool.Get(), bufPool.Get()
+	bufPool.Put(a)
+	bufPool.Put(b)
+	for i := 0; i < 2; i++ {
+		if got, ok := bufPool.TryGet(); !ok || (got != a && got != b) {
+			t.Fatalf("TryGet on partially-filled pool: got %v, %v", got, ok)
+		}
+	}
+
+	if _, ok := bufPool.TryGet(); ok {
+		t.Fatalf("TryGet on drained pool succeeded")
+	}
+	if misses := bufPool.Stats().Misses; misses != 2 {
+		t.Fatalf("TryGet allocated: got %v misses want %v", misses, 2)
+	}
+}
+
+func TestSizedBufferPoolDrain(t *testing.T) {
+	var size int = 4
+
+	bufPool := NewSizedBufferPool(size, 64)
+	for i := 0; i < size; i++ {
+		bufPool.Put(bytes.NewBuffer(make([]byte, 0, 64)))
+	}
+
+	bufPool.Drain()
+	if bufPool.Len() != 0 {
+		t.Fatalf("drained pool not empty: got %v want %v", bufPool.Len(), 0)
+	}
+
+	// The pool is still usable.
+	bufPool.Put(bufPool.Get())
+	if bufPool.Len() != 1 {
+		t.Fatalf("pool unusable after Drain: got %v want %v", bufPool.Len(), 1)
+	}
+}
+
+func TestSizedBufferPoolTrimN(t *testing.T) {
+	bufPool := NewSizedBufferPool(5, 64)
+	for i := 0; i < 5; i++ {
+		bufPool.Put(bytes.NewBuffer(make([]byte, 0, 64)))
+	}
+
+	if n := bufPool.Trim(3); n != 3 {
+		t.Fatalf("trimmed buffers invalid: got %v want %v", n, 3)
+	}
+	if n := bufPool.Len(); n != 2 {
+		t.Fatalf("len after trim invalid: got %v want %v", n, 2)
+	}
+	if n := bufPool.Trim(3); n != 2 {
+		t.Fatalf("trimmed buffers of short pool invalid: got %v want %v", n, 2)
+	}
+	if n := bufPool.Len(); n != 0 {
+		t.Fatalf("len after second trim invalid: got %v want %v", n, 0)
+	}
+}
+
+func TestSizedBufferPoolWithBuffer(t *testing.T) {
+	bufPool := NewSizedBufferPool(4, 64)
+
+	errTest := errors.New("test")
+	err := bufPool.WithBuffer(func(b *bytes.Buffer) error {
+		b.WriteString("hello")
+		return errTest
+	})
+	if err != errTest {
+		t.Fatalf("WithBuffer error: got %v want %v", err, errTest)
+	}
+	if bufPool.Len() != 1 {
+		t.Fatalf("buffer not returned: got %v want %v", bufPool.Len(), 1)
+	}
+
+	// A panicking callback still returns the buffer.
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Fatalf("WithBuffer swallowed the panic")
+			}
+		}()
+		bufPool.WithBuffer(func(b *bytes.Buffer) error {
+			panic("test")
+		})
+	}()
+	if bufPool.Len() != 1 {
+		t.Fatalf("buffer not returned after panic: got %v want %v",
+			bufPool.Len(), 1)
+	}
+}
+
+func TestSizedBufferPoolPrefill(t *testing.T) {
+	var size int = 4
+
+	bufPool := NewSizedBufferPool(size, 64)
+	bufPool.Prefill()
+	if bufPool.Len() != size {
+		t.Fatalf("prefilled pool size invalid: got %v want %v", bufPool.Len(), size)
+	}
+
+	bufPool.Get()
+	if misses := bufPool.Stats().Misses; misses != 0 {
+		t.Fatalf("Get on prefilled pool allocated: got %v misses want %v", misses, 0)
+	}
+}
+
+func TestSizedBufferPoolGetWithHint(t *testing.T) {
+	var capacity int = 64
+	var hint int = 4096
+
+	bufPool := NewSizedBufferPool(4, capacity)
+
+	b := bufPool.GetWithHint(hint)
+	if b.Cap() < hint {
+		t.Fatalf("new buffer capacity too small: got %v want >= %v", b.Cap(), hint)
+	}
+
+	bufPool.Put(bytes.NewBuffer(make([]byte, 0, capacity)))
+	b = bufPool.GetWithHint(hint)
+	if b.Cap() < hint {
+		t.Fatalf("reused buffer capacity too small: got %v want >= %v", b.Cap(),
+			hint)
+	}
+
+	// A hint below the pool's capacity doesn't shrink new buffers.
+	if b = bufPool.GetWithHint(1); b.Cap() != capacity {
+		t.Fatalf("new buffer capacity invalid: got %v want %v", b.Cap(), capacity)
+	}
+}
+
+var hintPayload = make([]byte, 500<<10)
+
+// BenchmarkSizedBufferPoolGetWithHint compares the allocations needed to
+// write a large payload into a new buffer with and without a size hint.
+func BenchmarkSizedBufferPoolGetWithHint(b *testing.B) {
+	bufPool := NewSizedBufferPool(0, 1024)
+
+	b.Run("Get", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			buf := bufPool.Get()
+			buf.Write(hintPayload)
+			bufPool.Put(buf)
+		}
+	})
+	b.Run("GetWithHint", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			buf := bufPool.GetWithHint(len(hintPayload))
+			buf.Write(hintPayload)
+			bufPool.Put(buf)
+		}
+	})
+}
+
+// TestSizedBufferPoolCapFloor checks that returning many small buffers
+// doesn't lower the capacity of new buffers.
+func TestSizedBufferPoolCapFloor(t *testing.T) {
+	var capacity int = 1024
+
+	bufPool := NewSizedBufferPool(4, capacity)
+	for i := 0; i < 1000; i++ {
+		bufPool.Put(bytes.NewBuffer(make([]byte, 0, 16)))
+		if bufPool.Cap() < capacity {
+			t.Fatalf("pool cap dropped below alloc: got %v want >= %v",
+				bufPool.Cap(), capacity)
+		}
+	}
+}
+
+// TestSizedBufferPoolExactCap documents that new buffers get exactly the
+// configured capacity, without any rounding.
+func TestSizedBufferPoolExactCap(t *testing.T) {
+	for _, alloc := range []int{1, 63, 64, 65, 1000, 1024, 4097} {
+		bufPool := NewSizedBufferPool(1, alloc)
+		if b := bufPool.Get(); b.Cap() != alloc {
+			t.Fatalf("alloc %v: buffer capacity invalid: got %v want %v", alloc,
+				b.Cap(), alloc)
+		}
+	}
+}
+
+func TestSizedBufferPoolSuggestedCap(t *testing.T) {
+	for name, opts := range map[string][]Option{
+		"plain":     nil,
+		"headroom":  {WithHeadroom(1.5)},
+		"sizeclass": {WithGoSizeClasses(true)},
+		"both":      {WithHeadroom(1.5), WithGoSizeClasses(true)},
+		"maxcap":    {WithHeadroom(4), WithMaxCap(2000)},
+		"template":  {WithTemplate(make([]byte, 3000)), WithGoSizeClasses(true)},
+		"alignment": {WithAlignment(64), WithGoSizeClasses(true)},
+	} {
+		bufPool := NewSizedBufferPoolWithOptions(append([]Option{WithSize(1),
+			WithAlloc(1000)}, opts...)...)
+		want := bufPool.Get().Cap()
+		if got := bufPool.SuggestedCap(); got != want {
+			t.Fatalf("%v: suggested capacity invalid: got %v want %v", name, got,
+				want)
+		}
+	}
+}
+
+// TestSizedBufferPoolMaxCapAlternating checks that a mix of tiny and huge
+// buffers leaves only small buffers in the pool and the pool cap unchanged.
+func TestSizedBufferPoolMaxCapAlternating(t *testing.T) {
+	var capacity int = 64
+	var maxCap int = 1024
+
+	bufPool := NewSizedBufferPoolWithMax(16, capacity, maxCap)
+	for i := 0; i < 16; i++ {
+		if i%2 == 0 {
+			bufPool.Put(bytes.NewBuffer(make([]byte, 0, 16)))
+		} else {
+			bufPool.Put(bytes.NewBuffer(make([]byte, 0, 1<<20)))
+		}
+	}
+
+	if bufPool.Cap() != capacity {
+		t.Fatalf("pool cap changed: got %v want %v", bufPool.Cap(), capacity)
+	}
+	close(bufPool.ch())
+	for buffer := range bufPool.ch() {
+		if buffer.Cap() > maxCap {
+			t.Fatalf("huge buffer retained: got capacity %v", buffer.Cap())
+		}
+	}
+}
+
+func TestSizedBufferPoolGrowReused(t *testing.T) {
+	var capacity int = 1024
+
+	bufPool := NewSizedBufferPool(4, capacity)
+	bufPool.Put(bytes.NewBuffer(make([]byte, 0, 16)))
+
+	if b := bufPool.Get(); b.Cap() < capacity {
+		t.Fatalf("undersized buffer not grown: got %v want >= %v", b.Cap(),
+			capacity)
+	}
+}
+
+// BenchmarkSizedBufferPoolGrowReused measures a steady state in which the
+// pool is fed undersized buffers that are then filled to the pool capacity.
+func BenchmarkSizedBufferPoolGrowReused(b *testing.B) {
+	bufPool := NewSizedBufferPool(4, len(payload))
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		bufPool.Put(bytes.NewBuffer(make([]byte, 0, 64)))
+		buf := bufPool.Get()
+		buf.Write(payload[:len(payload)/4])
+		buf.Write(payload[:len(payload)/4])
+		buf.Write(payload[:len(payload)/4])
+		buf.Write(payload[:len(payload)/4])
+	}
+}
+
+func TestSizedBufferPoolString(t *testing.T) {
+	bufPool := NewSizedBufferPool(8, 4096)
+	for i := 0; i < 3; i++ {
+		bufPool.Put(bytes.NewBuffer(make([]byte, 0, 4096)))
+	}
+
+	want := "SizedBufferPool{idle: 3/8, cap: 4096}"
+	if got := bufPool.String(); got != want {
+		t.Fatalf("String invalid: got %q want %q", got, want)
+	}
+}
+
+func TestSizedBufferPoolPutNil(t *testing.T) {
+	bufPool := NewSizedBufferPool(4, 64)
+
+	bufPool.Put(nil)
+	if bufPool.Len() != 0 || bufPool.Cap() != 64 {
+		t.Fatalf("Put(nil) changed the pool: got len %v cap %v", bufPool.Len(),
+			bufPool.Cap())
+	}
+	if b := bufPool.Get(); b == nil {
+		t.Fatalf("Get returned nil after Put(nil)")
+	}
+}
+
+func TestSizedBufferPoolPeak(t *testing.T) {
+	bufPool := NewSizedBufferPool(4, 64)
+
+	a, b := bufPool.Get(), bufPool.Get()
+	bufPool.Put(a)
+	c, d := bufPool.Get(), bufPool.Get() // three outstanding
+	bufPool.Put(b)
+	bufPool.Put(c)
+	bufPool.Put(d)
+	bufPool.Get()
+
+	if bufPool.Peak() != 3 {
+		t.Fatalf("peak invalid: got %v want %v", bufPool.Peak(), 3)
+	}
+}
+
+func TestSizedBufferPoolClone(t *testing.T) {
+	bufPool := NewSizedBufferPoolWithMax(4, 64, 1024)
+	bufPool.Put(bufPool.Get())
+
+	clone := bufPool.Clone()
+	if cap(clone.ch()) != 4 || clone.Cap() != 64 || clone.m != 1024 {
+		t.Fatalf("clone configuration invalid: got size %v alloc %v maxCap %v",
+			cap(clone.ch()), clone.Cap(), clone.m)
+	}
+	if clone.Len() != 0 || clone.Stats() != (Stats{}) {
+		t.Fatalf("clone not empty: got len %v stats %+v", clone.Len(), clone.Stats())
+	}
+
+	clone.Put(clone.Get())
+	clone.Put(bytes.NewBuffer(make([]byte, 0, 64)))
+	if bufPool.Len() != 1 || clone.Len() != 2 {
+		t.Fatalf("clone not independent: got %v and %v idle buffers",
+			bufPool.Len(), clone.Len())
+	}
+}
+
+func TestSizedBufferPoolPutShrunkZeroAlloc(t *testing.T) {
+	bufPool := NewSizedBufferPool(4, 0)
+	b := bufPool.Get()
+	b.WriteString("grown")
+	bufPool.PutShrunk(b)
+	if got := bufPool.Get(); got == b {
+		t.Fatalf("grown buffer retained by PutShrunk with alloc 0: got cap %v want %v", got.Cap(), 0)
+	}
+}
+
+func TestSizedBufferPoolPutShrunk(t *testing.T) {
+	var capacity int = 1024
+
+	bufPool := NewSizedBufferPool(4, capacity)
+
+	b := bufPool.Get()
+	b.Grow(100 << 20)
+	bufPool.PutShrunk(b)
+
+	if bufPool.Len() != 1 {
+		t.Fatalf("replacement buffer not pooled: got %v want %v", bufPool.Len(), 1)
+	}
+	if got := bufPool.Get(); got == b || got.Cap() != capacity {
+		t.Fatalf("oversized buffer retained: got capacity %v want %v", got.Cap(),
+			capacity)
+	}
+}
+
+func TestSizedBufferPoolInvalidParams(t *testing.T) {
+	for _, tc := range []struct{ size, alloc int }{
+		{0, 64}, {-1, 64}, {4, -5}, {0, -5},
+	} {
+		if _, err := NewSizedBufferPoolE(tc.size, tc.alloc); !errors.Is(err, ErrInvalidConfig) {
+			t.Fatalf("NewSizedBufferPoolE(%v, %v): got %v want %v", tc.size,
+				tc.alloc, err, ErrInvalidConfig)
+		}
+
+		// The plain constructor normalizes the parameters instead.
+		bufPool := NewSizedBufferPool(tc.size, tc.alloc)
+		bufPool.Put(bufPool.Get())
+		if cap(bufPool.ch()) < 0 || bufPool.Cap() < 0 {
+			t.Fatalf("NewSizedBufferPool(%v, %v) not normalized", tc.size, tc.alloc)
+		}
+	}
+
+	if _, err := NewSizedBufferPoolE(4, 0); err != nil {
+		t.Fatalf("NewSizedBufferPoolE(4, 0) failed: %v", err)
+	}
+}
+
+func TestSizedBufferPoolGetGrow(t *testing.T) {
+	var capacity int = 64
+	var minCap int = 4096
+
+	bufPool := NewSizedBufferPool(4, capacity)
+
+	// Empty pool.
+	if b := bufPool.GetGrow(minCap); b.Cap() < minCap {
+		t.Fatalf("new buffer too small: got %v want >= %v", b.Cap(), minCap)
+	}
+
+	// Reused buffer that is too small.
+	bufPool.Put(bytes.NewBuffer(make([]byte, 0, capacity)))
+	if b := bufPool.GetGrow(minCap); b.Cap() < minCap {
+		t.Fatalf("small reused buffer not grown: got %v want >= %v", b.Cap(),
+			minCap)
+	}
+
+	// Reused buffer that is large enough is handed out as is.
+	big := bytes.NewBuffer(make([]byte, 0, minCap*2))
+	bufPool.Put(big)
+	if b := bufPool.GetGrow(minCap); b != big || b.Cap() != minCap*2 {
+		t.Fatalf("adequate reused buffer not reused: got capacity %v", b.Cap())
+	}
+}
+
+// TestSizedBufferPoolNoAllocs checks that a warm Get/write/Put cycle doesn't
+// allocate.
+func TestSizedBufferPoolNoAllocs(t *testing.T) {
+	bufPool := NewSizedBufferPool(4, 1024)
+	bufPool.Prefill()
+	data := []byte("hello")
+
+	allocs := testing.AllocsPerRun(1000, func() {
+		b := bufPool.Get()
+		b.Write(data)
+		bufPool.Put(b)
+	})
+	if allocs != 0 {
+		t.Fatalf("steady-state Get/Put allocated: got %v allocs want %v", allocs, 0)
+	}
+}
+
+func TestSizedBufferPoolPutDiscardsMinimum(t *testing.T) {
+	// Producers only: every Put beyond the pool size must be discarded, and
+	// no other.
+	const size, producers = 4, 16
+	bufPool := NewSizedBufferPool(size, 64)
+	var wg sync.WaitGroup
+	for i := 0; i < producers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			bufPool.Put(bytes.NewBuffer(make([]byte, 0, 64)))
+		}()
+	}
+	wg.Wait()
+	if got := bufPool.Stats().Discards; got != producers-size {
+		t.Fatalf("discards invalid: got %v want %v", got, producers-size)
+	}
+	if bufPool.Len() != size {
+		t.Fatalf("pool not full: got %v want %v", bufPool.Len(), size)
+	}
+
+	// Balanced producers and consumers: with room for twice as many buffers
+	// as can be in flight, the pool never fills up and nothing is discarded.
+	const workers = 4
+	bufPool = NewSizedBufferPool(2*workers, 64)
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 1000; j++ {
+				bufPool.Put(bufPool.Get())
+			}
+		}()
+	}
+	wg.Wait()
+	if got := bufPool.Stats().Discards; got != 0 {
+		t.Fatalf("discards invalid: got %v want 0", got)
+	}
+}
+
+func TestSizedBufferPoolPutReported(t *testing.T) {
+	bufPool := NewSizedBufferPoolWithMax(1, 64, 128)
+
+	if !bufPool.PutReported(bufPool.Get()) {
+		t.Fatalf("buffer put into empty pool not reported as retained")
+	}
+	if bufPool.PutReported(bytes.NewBuffer(make([]byte, 0, 64))) {
+		t.Fatalf("buffer put into full pool reported as retained")
+	}
+
+	bufPool.Get()
+	if !bufPool.PutReported(bytes.NewBuffer(make([]byte, 0, 256))) {
+		t.Fatalf("replaced oversized buffer not reported as retained")
+	}
+	if bufPool.PutReported(nil) {
+		t.Fatalf("nil buffer reported as retained")
+	}
+}
+
+func TestSizedBufferPoolSetCap(t *testing.T) {
+	bufPool := NewSizedBufferPool(2, 64)
+	bufPool.Put(bufPool.Get())
+
+	bufPool.SetCap(1024)
+	if bufPool.Cap() != 1024 {
+		t.Fatalf("capacity not changed: got %v want %v", bufPool.Cap(), 1024)
+	}
+
+	// The idle buffer is grown, and new buffers have the new capacity.
+	for i := 0; i < 2; i++ {
+		if b := bufPool.Get(); b.Cap() < 1024 {
+			t.Fatalf("buffer %d capacity invalid: got %v want at least %v", i, b.Cap(), 1024)
+		}
+	}
+	if clone := bufPool.Clone(); clone.Cap() != 1024 {
+		t.Fatalf("clone capacity invalid: got %v want %v", clone.Cap(), 1024)
+	}
+
+	// The capacity never exceeds the limit Put retains.
+	bufPool = NewSizedBufferPoolWithMax(2, 64, 512)
+	bufPool.SetCap(1024)
+	if bufPool.Cap() != 512 {
+		t.Fatalf("capacity above max cap invalid: got %v want %v", bufPool.Cap(), 512)
+	}
+}
+
+func TestSizedBufferPoolPrefillContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// Cancel the context while the fourth buffer is allocated.
+	allocated := 0
+	bufPool := NewSizedBufferPoolWithOptions(WithSize(10), WithAlloc(64),
+		WithAllocFunc(func(capacity int) *bytes.Buffer {
+			allocated++
+			if allocated == 4 {
+				cancel()
+			}
+			return bytes.NewBuffer(make([]byte, 0, capacity))
+		}))
+
+	if err := bufPool.PrefillContext(ctx); err != context.Canceled {
+		t.Fatalf("cancelled prefill invalid: got %v want %v", err, context.Canceled)
+	}
+	if bufPool.Len() != 4 {
+		t.Fatalf("partial fill invalid: got %v want %v", bufPool.Len(), 4)
+	}
+
+	if err := bufPool.PrefillContext(context.Background()); err != nil {
+		t.Fatalf("prefill failed: %v", err)
+	}
+	if bufPool.Len() != 10 {
+		t.Fatalf("pool not full: got %v want %v", bufPool.Len(), 10)
+	}
+}
+
+func TestSizedBufferPoolGetGrowE(t *testing.T) {
+	bufPool := NewSizedBufferPool(2, 64)
+
+	b, err := bufPool.GetGrowE(1024)
+	if err != nil || b.Cap() < 1024 {
+		t.Fatalf("GetGrowE failed: got %v", err)
+	}
+	bufPool.Put(b)
+
+	// The idle buffer has to grow, and a new one has to be allocated.
+	for i := 0; i < 2; i++ {
+		b, err = bufPool.GetGrowE(math.MaxInt)
+		if !errors.Is(err, ErrTooLarge) || b != nil {
+			t.Fatalf("absurd GetGrowE invalid: got %v want %v", err, ErrTooLarge)
+		}
+	}
+	if n := bufPool.o.Load(); n != 0 {
+		t.Fatalf("failed Gets counted as outstanding: got %v want 0", n)
+	}
+}
+
+func TestSizedBufferPoolGetGrowEBug(t *testing.T) {
+	// A bug in an allocation function isn't mistaken for a failed allocation.
+	bufPool := NewSizedBufferPoolWithOptions(WithSize(2), WithAlloc(64),
+		WithAllocFunc(func(capacity int) *bytes.Buffer {
+			var b *bytes.Buffer
+			b.Grow(capacity)
+			return b
+		}))
+	defer func() {
+		if r := recover(); r == nil {
+			t.Fatalf("GetGrowE swallowed a nil dereference")
+		}
+	}()
+	bufPool.GetGrowE(1024)
+}
+
+func TestIsAllocPanic(t *testing.T) {
+	catch := func(fn func()) (err error) {
+		defer func() {
+			err, _ = recover().(error)
+		}()
+		fn()
+		return
+	}
+	n := -1
+	var s []int
+	for _, tc := range []struct {
+		name string
+		fn   func()
+		want bool
+	}{
+		{"makeslice", func() { _ = make([]byte, n) }, true},
+		{"bytes.ErrTooLarge", func() { panic(bytes.ErrTooLarge) }, true},
+		{"index out of range", func() { _ = s[-n] }, false},
+		{"nil dereference", func() { var b *bytes.Buffer; _ = b.Len() }, false},
+	} {
+		if got := isAllocPanic(catch(tc.fn)); got != tc.want {
+			t.Fatalf("isAllocPanic of %v invalid: got %v want %v", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestSizedBufferPoolSafePut(t *testing.T) {
+	bufPool := NewSizedBufferPoolWithOptions(WithSize(2), WithAlloc(64),
+		WithZeroOnPut(true))
+
+	b := bufPool.Get()
+	b.WriteString("hello")
+	aliased := b.Bytes()
+	bufPool.SafePut(b, true)
+
+	if string(aliased) != "hello" {
+		t.Fatalf("aliased contents changed: got %q want %q", aliased, "hello")
+	}
+	got := bufPool.Get()
+	if got == b {
+		t.Fatalf("aliased buffer reused")
+	}
+	got.WriteString("world")
+	if string(aliased) != "hello" {
+		t.Fatalf("aliased contents overwritten: got %q want %q", aliased, "hello")
+	}
+	if s := bufPool.Stats(); s.Puts != 1 || s.Discards != 1 {
+		t.Fatalf("stats invalid: got %+v", s)
+	}
+
+	// Unaliased buffers are recycled as by Put.
+	bufPool.SafePut(got, false)
+	if again := bufPool.Get(); again != got {
+		t.Fatalf("unaliased buffer not reused")
+	}
+}
+
+func TestSizedBufferPoolGetExact(t *testing.T) {
+	bufPool := NewSizedBufferPool(2, 64)
+
+	for _, n := range []int{1, 63, 100, 1000} {
+		if b := bufPool.GetExact(n); b.Cap() != n {
+			t.Fatalf("GetExact(%v) capacity invalid: got %v want %v", n, b.Cap(), n)
+		}
+	}
+
+	// An idle buffer that is large enough is reused, one that is too small
+	// is left in the pool.
+	b := bytes.NewBuffer(make([]byte, 0, 64))
+	bufPool.Put(b)
+	if got := bufPool.GetExact(100); got == b || got.Cap() != 100 {
+		t.Fatalf("too small idle buffer reused")
+	}
+	if bufPool.Len() != 1 {
+		t.Fatalf("too small idle buffer dropped: got %v idle want %v", bufPool.Len(), 1)
+	}
+	if got := bufPool.GetExact(32); got != b {
+		t.Fatalf("idle buffer not reused")
+	}
+}
+
+func TestSizedBufferPoolGetZeroed(t *testing.T) {
+	bufPool := NewSizedBufferPool(2, 64)
+
+	dirty := bufPool.Get()
+	dirty.WriteString("leftover data")
+	bufPool.Put(dirty)
+
+	b := bufPool.GetZeroed(32)
+	if b != dirty {
+		t.Fatalf("pooled storage not reused")
+	}
+	if b.Len() != 32 || !bytes.Equal(b.Bytes(), make([]byte, 32)) {
+		t.Fatalf("buffer not zeroed: got %q", b.Bytes())
+	}
+
+	if b := bufPool.GetZeroed(1000); b.Len() != 1000 || b.Cap() < 1000 {
+		t.Fatalf("large zeroed buffer invalid: got len %v cap %v", b.Len(), b.Cap())
+	}
+}
+
+func TestSizedBufferPoolGetCopyOf(t *testing.T) {
+	from, to := NewSizedBufferPool(2, 16), NewSizedBufferPool(2, 64)
+
+	src := from.Get()
+	src.WriteString(strings.Repeat("data", 100))
+	b := to.GetCopyOf(src)
+	if !bytes.Equal(b.Bytes(), src.Bytes()) {
+		t.Fatalf("copy contents invalid: got %q want %q", b.Bytes(), src.Bytes())
+	}
+	if b.Cap() < src.Len() || b == src {
+		t.Fatalf("copy capacity invalid: got %v want at least %v", b.Cap(), src.Len())
+	}
+	from.Put(src)
+
+	if b := to.GetCopyOf(bytes.NewBufferString("x")); b.Cap() != 64 || b.String() != "x" {
+		t.Fatalf("small copy invalid: got %q cap %v", b.String(), b.Cap())
+	}
+}
+
+func TestSizedBufferPoolPromote(t *testing.T) {
+	bufPool := NewSizedBufferPool(2, 64)
+
+	b := bufPool.Get()
+	b.WriteString("header")
+	if got := bufPool.Promote(b, 32); got != b {
+		t.Fatalf("large enough buffer not promoted in place")
+	}
+
+	got := bufPool.Promote(b, 1024)
+	if got == b || got.Cap() < 1024 {
+		t.Fatalf("promoted buffer capacity invalid: got %v want at least %v", got.Cap(), 1024)
+	}
+	if got.String() != "header" {
+		t.Fatalf("promoted buffer contents invalid: got %q want %q", got.String(), "header")
+	}
+	if bufPool.Len() != 1 {
+		t.Fatalf("small buffer not returned: got %v idle want %v", bufPool.Len(), 1)
+	}
+}
+
+func TestSizedBufferPoolWouldRetain(t *testing.T) {
+	bufPool := NewSizedBufferPoolWithMax(1, 64, 128)
+
+	small := bytes.NewBuffer(make([]byte, 0, 64))
+	big := bytes.NewBuffer(make([]byte, 0, 256))
+	if !bufPool.WouldRetain(small) {
+		t.Fatalf("buffer not retained by an empty pool")
+	}
+	if bufPool.WouldRetain(big) {
+		t.Fatalf("oversized buffer retained")
+	}
+
+	bufPool.Put(bufPool.Get())
+	if bufPool.WouldRetain(small) {
+		t.Fatalf("buffer retained by a full pool")
+	}
+	if got := bufPool.Stats(); got.Puts != 1 || got.Discards != 0 {
+		t.Fatalf("WouldRetain has side effects: got %+v", got)
+	}
+}
+
+// TestSizedBufferPoolNoStaleData checks that buffers handed out by the pool
+// never expose data written before they were returned, whether the buffer
+// itself was retained or an oversized one was replaced.
+func TestSizedBufferPoolNoStaleData(t *testing.T) {
+	bufPool := NewSizedBufferPoolWithMax(1, 64, 128)
+
+	checkEmpty := func(what string, b *bytes.Buffer) {
+		t.Helper()
+		if b.Len() != 0 {
+			t.Fatalf("%s buffer not empty: got len %v", what, b.Len())
+		}
+		p := make([]byte, 16)
+		if n, err := b.Read(p); n != 0 || err != io.EOF {
+			t.Fatalf("%s buffer readable: got %v bytes, %v want 0, %v", what, n, err, io.EOF)
+		}
+	}
+
+	b := bufPool.Get()
+	b.WriteString("hello")
+	bufPool.Put(b)
+	got := bufPool.Get()
+	if got != b {
+		t.Fatalf("buffer not retained")
+	}
+	checkEmpty("retained", got)
+
+	got.WriteString("hello")
+	got.Write(make([]byte, 1024))
+	bufPool.Put(got)
+	replacement := bufPool.Get()
+	if replacement == got {
+		t.Fatalf("oversized buffer retained")
+	}
+	checkEmpty("replacement", replacement)
+}
+
+func TestSizedBufferPoolResetAll(t *testing.T) {
+	bufPool := NewSizedBufferPoolWithOptions(WithSize(2), WithAlloc(64),
+		WithCapHistogram([]int{128}))
+
+	// Dirty the pool.
+	a, b, c := bufPool.Get(), bufPool.Get(), bufPool.Get()
+	bufPool.PutN([]*bytes.Buffer{a, b, c})
+	bufPool.Resize(8)
+	bufPool.SetCap(1024)
+
+	bufPool.ResetAll()
+
+	if bufPool.Len() != 0 || bufPool.idleCap() != 2 || bufPool.Cap() != 64 {
+		t.Fatalf("pool not reset: got %v", bufPool)
+	}
+	if got := bufPool.Stats(); got != (Stats{}) {
+		t.Fatalf("stats not reset: got %+v", got)
+	}
+	if bufPool.Peak() != 0 {
+		t.Fatalf("peak not reset: got %v", bufPool.Peak())
+	}
+	for i, n := range bufPool.CapHistogram() {
+		if n != 0 {
+			t.Fatalf("histogram bucket %d not reset: got %v", i, n)
+		}
+	}
+}
+
+func TestSizedBufferPoolGetWait(t *testing.T) {
+	bufPool := NewSizedBufferPool(1, 64)
+
+	// Nothing is returned during the wait, so a new buffer is allocated.
+	start := time.Now()
+	if b := bufPool.GetWait(10 * time.Millisecond); b == nil || b.Cap() != 64 {
+		t.Fatalf("GetWait on empty pool invalid: got %v", b)
+	}
+	if waited := time.Since(start); waited < 10*time.Millisecond {
+		t.Fatalf("GetWait didn't wait: got %v", waited)
+	}
+	if misses := bufPool.Stats().Misses; misses != 1 {
+		t.Fatalf("misses invalid: got %v want %v", misses, 1)
+	}
+
+	// A buffer returned during the wait is reused.
+	returned := bytes.NewBuffer(make([]byte, 0, 64))
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		bufPool.Put(returned)
+	}()
+	if b := bufPool.GetWait(time.Minute); b != returned {
+		t.Fatalf("GetWait didn't reuse the returned buffer")
+	}
+	if misses := bufPool.Stats().Misses; misses != 1 {
+		t.Fatalf("misses invalid: got %v want %v", misses, 1)
+	}
+}
+
+// TestSizedBufferPoolExclusiveOwnership checks that the pool never hands the
+// same buffer to two callers at once: each caller claims the buffer it gets
+// and writes its own marker into it, and the claim and the marker must still
+// be intact when it puts the buffer back.
+func TestSizedBufferPoolExclusiveOwnership(t *testing.T) {
+	for _, lifo := range []bool{false, true} {
+		bufPool := NewSizedBufferPoolWithOptions(WithSize(4), WithAlloc(64),
+			WithLIFO(lifo))
+		var mu sync.Mutex
+		owner := make(map[*bytes.Buffer]int)
+
+		var wg sync.WaitGroup
+		for id := 1; id <= 32; id++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				marker := strings.Repeat(string(rune('A'+id%26)), id)
+				for i := 0; i < 200; i++ {
+					b := bufPool.Get()
+					mu.Lock()
+					if o := owner[b]; o != 0 {
+						t.Errorf("lifo %v: buffer handed out twice: got owner %v want none", lifo, o)
+					}
+					owner[b] = id
+					mu.Unlock()
+
+					b.WriteString(marker)
+					runtime.Gosched()
+					if got := b.String(); got != marker {
+						t.Errorf("lifo %v: buffer mutated while owned: got %q want %q", lifo, got, marker)
+					}
+
+					mu.Lock()
+					if o := owner[b]; o != id {
+						t.Errorf("lifo %v: buffer owner invalid: got %v want %v", lifo, o, id)
+					}
+					owner[b] = 0
+					mu.Unlock()
+					bufPool.Put(b)
+				}
+			}()
+		}
+		wg.Wait()
+	}
+}
+
+func TestSizedBufferPoolPutWithCleanup(t *testing.T) {
+	bufPool := NewSizedBufferPoolWithOptions(WithSize(1), WithAlloc(8), WithMaxCap(64))
+	var cleanups int
+	onDiscard := func() { cleanups++ }
+
+	bufPool.PutWithCleanup(bytes.NewBuffer(make([]byte, 0, 8)), onDiscard)
+	if cleanups != 0 {
+		t.Fatalf("cleanups after retained put invalid: got %v want %v", cleanups, 0)
+	}
+	if n := bufPool.Len(); n != 1 {
+		t.Fatalf("len after retained put invalid: got %v want %v", n, 1)
+	}
+
+	bufPool.PutWithCleanup(bytes.NewBuffer(make([]byte, 0, 8)), onDiscard)
+	if cleanups != 1 {
+		t.Fatalf("cleanups after put into full pool invalid: got %v want %v", cleanups, 1)
+	}
+
+	bufPool.Get()
+	bufPool.PutWithCleanup(bytes.NewBuffer(make([]byte, 0, 128)), onDiscard)
+	if cleanups != 2 {
+		t.Fatalf("cleanups after oversized put invalid: got %v want %v", cleanups, 2)
+	}
+	if n := bufPool.Len(); n != 1 {
+		t.Fatalf("len after oversized put invalid: got %v want %v", n, 1)
+	}
+}
+
+func TestSizedBufferPoolPutCap(t *testing.T) {
+	bufPool := NewSizedBufferPool(4, 16)
+	b := bufPool.Get()
+	b.Write(make([]byte, 100))
+	want := b.Cap()
+	if c := bufPool.PutCap(b); c != want || c < 100 {
+		t.Fatalf("cap of grown buffer invalid: got %v want %v", c, want)
+	}
+	if bufPool.Len() != 1 {
+		t.Fatalf("buffer not retained: got %v want %v", bufPool.Len(), 1)
+	}
+	if c := bufPool.PutCap(nil); c != 0 {
+		t.Fatalf("cap of nil buffer invalid: got %v want %v", c, 0)
+	}
+}
+
+func TestNewSizedBufferPoolForConcurrency(t *testing.T) {
+	defer runtime.GOMAXPROCS(runtime.GOMAXPROCS(3))
+	bufPool := NewSizedBufferPoolForConcurrency(4, 64)
+	if n, want := bufPool.idleCap(), 12; n != want {
+		t.Fatalf("size invalid: got %v want %v", n, want)
+	}
+	if bufPool.Cap() != 64 {
+		t.Fatalf("capacity invalid: got %v want %v", bufPool.Cap(), 64)
+	}
+}
@@ -0,0 +1,72 @@
+package bpool
+
+import (
+	"bytes"
+	"math/bits"
+	"sync/atomic"
+)
+
+// WithStandby keeps one buffer per power-of-two capacity class that grew past
+// the limit set with WithMaxCap in a standby slot instead of discarding it on
+// Put, so that a later GetGrow or GetWithHint for that size reuses it rather
+// than allocating. Unlike WithOverflowPool, a request is only served by a
+// standby buffer of its own or the next larger class. Buffers are still
+// subject to WithAbsoluteMaxCap, and PutShrunk never uses the slots.
+func WithStandby(enabled bool) Option {
+	return func(o *options) {
+		o.standby = enabled
+	}
+}
+
+// standbySlots holds the standby buffers set up with WithStandby, indexed by
+// the bit length of their capacity.
+type standbySlots [bits.UintSize + 1]atomic.Pointer[bytes.Buffer]
+
+// park tries to keep an oversized buffer in the standby slot of its class,
+// and reports whether it did.
+func (bp *SizedBufferPool) park(b *bytes.Buffer) bool {
+	if !bp.wouldPark(b) {
+		return false
+	}
+	bp.reset(b)
+	return bp.standby[bits.Len(uint(b.Cap()))].CompareAndSwap(nil, b)
+}
+
+// wouldPark reports whether park would keep b right now.
+func (bp *SizedBufferPool) wouldPark(b *bytes.Buffer) bool {
+	if bp.standby == nil ||
+		(bp.opt.absoluteMaxCap > 0 && b.Cap() > bp.opt.absoluteMaxCap) {
+		return false
+	}
+	return bp.standby[bits.Len(uint(b.Cap()))].Load() == nil
+}
+
+// unpark takes a standby buffer with a capacity of at least minCap, if there
+// is one. Requests that fit the pool's capacity are left to the regular pool.
+func (bp *SizedBufferPool) unpark(minCap int) (*bytes.Buffer, bool) {
+	if bp.standby == nil || minCap <= bp.Cap() {
+		return nil, false
+	}
+	c := bits.Len(uint(minCap))
+	for i := c; i <= min(c+1, len(bp.standby)-1); i++ {
+		slot := &bp.standby[i]
+		b := slot.Load()
+		if b == nil || b.Cap() < minCap || !slot.CompareAndSwap(b, nil) {
+			continue
+		}
+		if bp.opt.zeroOnGet {
+			bp.scrub(b)
+		}
+		return bp.lend(b), true
+	}
+	return nil, false
+}
+
+// clearStandby releases the standby buffers.
+func (bp *SizedBufferPool) clearStandby() {
+	for i := range bp.standby {
+		if b := bp.standby[i].Swap(nil); b != nil {
+			bp.free(b)
+		}
+	}
+}
@@ -0,0 +1,61 @@
+package bpool
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestSizedBufferPoolStandby(t *testing.T) {
+	bufPool := NewSizedBufferPoolWithOptions(WithSize(4), WithAlloc(64),
+		WithMaxCap(128), WithStandby(true))
+
+	large := bytes.NewBuffer(make([]byte, 0, 5000))
+	large.WriteString("stale")
+	bufPool.Put(large)
+	if s := bufPool.Stats(); s.Discards != 0 || s.OversizeDiscards != 0 {
+		t.Fatalf("stats after parking invalid: got %+v want no discards", s)
+	}
+
+	// Requests of another class aren't served by the standby buffer.
+	if b := bufPool.GetGrow(1000); b == large {
+		t.Fatalf("standby buffer served a request of a smaller class")
+	}
+	misses := bufPool.Stats().Misses
+	b := bufPool.GetGrow(4096)
+	if b != large || b.Len() != 0 {
+		t.Fatalf("GetGrow invalid: got cap %v, len %v want the standby buffer", b.Cap(), b.Len())
+	}
+	if m := bufPool.Stats().Misses; m != misses {
+		t.Fatalf("misses invalid: got %v want %v", m, misses)
+	}
+	if b := bufPool.GetGrow(4096); b == large {
+		t.Fatalf("standby buffer served twice")
+	}
+
+	// Drain releases the standby buffers too.
+	bufPool.Put(large)
+	bufPool.Drain()
+	if b := bufPool.GetGrow(4096); b == large {
+		t.Fatalf("standby buffer served after Drain")
+	}
+}
+
+func TestSizedBufferPoolStandbyReported(t *testing.T) {
+	bufPool := NewSizedBufferPoolWithOptions(WithSize(1), WithAlloc(64),
+		WithMaxCap(128), WithStandby(true))
+	bufPool.Put(bytes.NewBuffer(make([]byte, 0, 64)))
+
+	large := bytes.NewBuffer(make([]byte, 0, 5000))
+	if !bufPool.WouldRetain(large) {
+		t.Fatalf("WouldRetain of buffer for a free standby slot invalid: got false want true")
+	}
+	if !bufPool.PutReported(large) {
+		t.Fatalf("PutReported of parked buffer into full pool invalid: got false want true")
+	}
+	if bufPool.WouldRetain(bytes.NewBuffer(make([]byte, 0, 5000))) {
+		t.Fatalf("WouldRetain of buffer for a taken standby slot invalid: got true want false")
+	}
+	if b := bufPool.GetGrow(4096); b != large {
+		t.Fatalf("GetGrow didn't return the parked buffer")
+	}
+}
@@ -0,0 +1,164 @@
+package bpool
+
+import (
+	"math"
+	"math/bits"
+	"sync/atomic"
+	"time"
+)
+
+// Stats reports the activity of a SizedBufferPool since it was created.
+type Stats struct {
+	Gets     uint64 // calls to Get
+	Puts     uint64 // calls to Put
+	Misses   uint64 // Gets that had to allocate a new buffer
+	Discards uint64 // Puts that threw the buffer away
+
+	// OversizeDiscards counts the Discards of buffers that had grown past
+	// the pool's maximum capacity. A steadily climbing count means the
+	// workload's buffer sizes don't fit a single pool, and it may be better
+	// served by a TieredBufferPool.
+	OversizeDiscards uint64
+
+	// PutReplaceAllocs counts the buffers allocated by Put to replace an
+	// oversized or aliased buffer. Unlike Misses, which mean the pool is too
+	// small, these mean buffers grow beyond the pool's maximum capacity.
+	PutReplaceAllocs uint64
+
+	// UnmatchedPutBytes counts the slices passed to PutBytes that didn't
+	// start at the backing array handed out by GetBytes, typically because
+	// an append reallocated them. Their original buffers are never returned
+	// to the pool.
+	UnmatchedPutBytes uint64
+
+	// AllocLatencyP50 and AllocLatencyP99 are the median and 99th
+	// percentile of the time taken to allocate a new buffer, i.e. the
+	// latency saved by every hit, rounded up to a power of two nanoseconds.
+	// They are only measured with WithAllocTiming, and are 0 otherwise.
+	AllocLatencyP50 time.Duration
+	AllocLatencyP99 time.Duration
+
+	// AvgUtilization is the average ratio of length to capacity of the
+	// buffers returned with Put, from 0 to 1. A low value means buffers are
+	// allocated larger than the data written to them.
+	AvgUtilization float64
+}
+
+// stats holds the live counters behind Stats. The counters are updated
+// atomically so that collecting them doesn't serialize Get and Put.
+type stats struct {
+	gets     atomic.Uint64
+	puts     atomic.Uint64
+	misses   atomic.Uint64
+	discards atomic.Uint64
+	oversize atomic.Uint64
+	replaced atomic.Uint64
+	// unmatched counts the slices PutBytes didn't recognize.
+	unmatched atomic.Uint64
+
+	// allocs counts allocations per latency bucket: bucket i holds those
+	// that took less than 2^i nanoseconds, and at least half as long.
+	allocs [64]atomic.Uint64
+
+	// utilSum is the sum of the utilizations of utilN returned buffers, in
+	// millionths.
+	utilSum atomic.Uint64
+	utilN   atomic.Uint64
+}
+
+// utilScale is the fixed-point scale of stats.utilSum.
+const utilScale = 1e6
+
+// recordUtil adds the utilization of a returned buffer of the given length
+// and capacity. Buffers without capacity are ignored.
+func (s *stats) recordUtil(length, capacity int) {
+	if capacity <= 0 {
+		return
+	}
+	s.utilSum.Add(uint64(float64(length) / float64(capacity) * utilScale))
+	s.utilN.Add(1)
+}
+
+// avgUtil returns the average utilization given the sum and count recorded
+// by recordUtil, or 0 if there are none.
+func avgUtil(sum, n uint64) float64 {
+	if n == 0 {
+		return 0
+	}
+	return float64(sum) / utilScale / float64(n)
+}
+
+// recordAlloc counts an allocation that took d in its latency bucket.
+func (s *stats) recordAlloc(d time.Duration) {
+	s.allocs[bits.Len64(uint64(max(d, 0)))].Add(1)
+}
+
+// allocLatency returns the p-th percentile of the allocation latencies
+// counted in buckets, rounded up to its bucket's bound, or 0 if there are
+// none.
+func allocLatency(buckets *[64]uint64, p int) time.Duration {
+	var total uint64
+	for _, n := range buckets {
+		total += n
+	}
+	if total == 0 {
+		return 0
+	}
+	rank := max((total*uint64(p)+99)/100, 1)
+	var seen uint64
+	for i, n := range buckets {
+		seen += n
+		if seen >= rank && i < 63 {
+			return time.Duration(1) << i
+		}
+	}
+	return math.MaxInt64
+}
+
+// snapshot returns the current values of the counters.
+func (s *stats) snapshot() Stats {
+	var allocs [64]uint64
+	for i := range s.allocs {
+		allocs[i] = s.allocs[i].Load()
+	}
+	return Stats{
+		Gets:     s.gets.Load(),
+		Puts:     s.puts.Load(),
+		Misses:   s.misses.Load(),
+		Discards: s.discards.Load(),
+
+		OversizeDiscards:  s.oversize.Load(),
+		PutReplaceAllocs:  s.replaced.Load(),
+		UnmatchedPutBytes: s.unmatched.Load(),
+
+		AllocLatencyP50: allocLatency(&allocs, 50),
+		AllocLatencyP99: allocLatency(&allocs, 99),
+
+		AvgUtilization: avgUtil(s.utilSum.Load(), s.utilN.Load()),
+	}
+}
+
+// swap returns the current values of the counters and resets them to zero.
+// Each counter is swapped atomically, so every event is reported by exactly
+// one call.
+func (s *stats) swap() Stats {
+	var allocs [64]uint64
+	for i := range s.allocs {
+		allocs[i] = s.allocs[i].Swap(0)
+	}
+	return Stats{
+		Gets:     s.gets.Swap(0),
+		Puts:     s.puts.Swap(0),
+		Misses:   s.misses.Swap(0),
+		Discards: s.discards.Swap(0),
+
+		OversizeDiscards:  s.oversize.Swap(0),
+		PutReplaceAllocs:  s.replaced.Swap(0),
+		UnmatchedPutBytes: s.unmatched.Swap(0),
+
+		AllocLatencyP50: allocLatency(&allocs, 50),
+		AllocLatencyP99: allocLatency(&allocs, 99),
+
+		AvgUtilization: avgUtil(s.utilSum.Swap(0), s.utilN.Swap(0)),
+	}
+}
@@ -0,0 +1,147 @@
+package bpool
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestSizedBufferPoolStats(t *testing.T) {
+	bufPool := NewSizedBufferPool(2, 64)
+
+	// Three misses on an empty pool.
+	a, b, c := bufPool.Get(), bufPool.Get(), bufPool.Get()
+
+	// Two buffers fit, the third is discarded.
+	bufPool.Put(a)
+	bufPool.Put(b)
+	bufPool.Put(c)
+
+	// Two hits.
+	bufPool.Get()
+	bufPool.Get()
+
+	want := Stats{Gets: 5, Puts: 3, Misses: 3, Discards: 1}
+	if got := bufPool.Stats(); got != want {
+		t.Fatalf("stats invalid: got %+v want %+v", got, want)
+	}
+}
+
+func TestSizedBufferPoolStatsAndReset(t *testing.T) {
+	bufPool := NewSizedBufferPool(2, 64)
+
+	bufPool.Put(bufPool.Get())
+	want := Stats{Gets: 1, Puts: 1, Misses: 1}
+	if got := bufPool.StatsAndReset(); got != want {
+		t.Fatalf("first interval invalid: got %+v want %+v", got, want)
+	}
+
+	bufPool.Get()
+	bufPool.Get()
+	want = Stats{Gets: 2, Misses: 1}
+	if got := bufPool.StatsAndReset(); got != want {
+		t.Fatalf("second interval invalid: got %+v want %+v", got, want)
+	}
+
+	if got := bufPool.Stats(); got != (Stats{}) {
+		t.Fatalf("stats not reset: got %+v", got)
+	}
+}
+
+func TestSizedBufferPoolOversizeDiscards(t *testing.T) {
+	bufPool := NewSizedBufferPoolWithMax(2, 64, 128)
+
+	// Alternate small and large writes, as a bimodal workload would.
+	for i := 0; i < 10; i++ {
+		b := bufPool.Get()
+		if i%2 == 1 {
+			b.Write(make([]byte, 1024))
+		}
+		bufPool.Put(b)
+	}
+
+	got := bufPool.Stats()
+	if got.OversizeDiscards != 5 {
+		t.Fatalf("oversize discards invalid: got %v want %v", got.OversizeDiscards, 5)
+	}
+	if got.Discards != 5 {
+		t.Fatalf("discards invalid: got %v want %v", got.Discards, 5)
+	}
+}
+
+func TestSizedBufferPoolAllocStats(t *testing.T) {
+	bufPool := NewSizedBufferPoolWithMax(2, 64, 128)
+
+	// Get-path allocations are misses.
+	b := bufPool.Get()
+	if got := bufPool.Stats(); got.Misses != 1 || got.PutReplaceAllocs != 0 {
+		t.Fatalf("get allocation counted wrong: got %+v", got)
+	}
+
+	// Put-path allocations replace oversized buffers.
+	b.Write(make([]byte, 1024))
+	bufPool.Put(b)
+	if got := bufPool.Stats(); got.Misses != 1 || got.PutReplaceAllocs != 1 {
+		t.Fatalf("put replacement counted wrong: got %+v", got)
+	}
+}
+
+func TestSizedBufferPoolAllocTiming(t *testing.T) {
+	bufPool := NewSizedBufferPool(1, 64)
+	bufPool.Get()
+	if got := bufPool.Stats(); got.AllocLatencyP99 != 0 {
+		t.Fatalf("allocation timed without WithAllocTiming: got %v", got.AllocLatencyP99)
+	}
+
+	bufPool = NewSizedBufferPoolWithOptions(WithSize(1), WithAlloc(64),
+		WithAllocTiming(true))
+	for i := 0; i < 10; i++ {
+		bufPool.Get()
+	}
+	got := bufPool.Stats()
+	if got.AllocLatencyP50 <= 0 || got.AllocLatencyP99 < got.AllocLatencyP50 {
+		t.Fatalf("allocation latencies invalid: got p50 %v p99 %v",
+			got.AllocLatencyP50, got.AllocLatencyP99)
+	}
+	if got := bufPool.StatsAndReset(); got.AllocLatencyP99 == 0 {
+		t.Fatalf("StatsAndReset lost allocation latencies")
+	}
+	if got := bufPool.Stats(); got.AllocLatencyP99 != 0 {
+		t.Fatalf("allocation latencies not reset: got %v", got.AllocLatencyP99)
+	}
+}
+
+func TestSizedBufferPoolFullNoReplaceAllocs(t *testing.T) {
+	bufPool := NewSizedBufferPoolWithMax(2, 64, 128)
+	bufPool.Prefill()
+
+	for i := 0; i < 10; i++ {
+		bufPool.Put(bytes.NewBuffer(make([]byte, 0, 1024)))
+	}
+	got := bufPool.Stats()
+	if got.PutReplaceAllocs != 0 {
+		t.Fatalf("full pool allocated replacements: got %v want %v", got.PutReplaceAllocs, 0)
+	}
+	if got.OversizeDiscards != 10 || bufPool.Len() != 2 {
+		t.Fatalf("oversized buffers not discarded: got %+v", got)
+	}
+}
+
+func TestSizedBufferPoolAvgUtilization(t *testing.T) {
+	bufPool := NewSizedBufferPool(4, 100)
+
+	// Utilizations of 0.25 and 0.75.
+	for _, n := range []int{25, 75} {
+		b := bytes.NewBuffer(make([]byte, 0, 100))
+		b.Write(make([]byte, n))
+		bufPool.Put(b)
+	}
+	if got := bufPool.Stats().AvgUtilization; got != 0.5 {
+		t.Fatalf("average utilization invalid: got %v want %v", got, 0.5)
+	}
+	if got := bufPool.StatsAndReset().AvgUtilization; got != 0.5 {
+		t.Fatalf("average utilization not reported on reset: got %v want %v", got, 0.5)
+	}
+	if got := bufPool.Stats().AvgUtilization; got != 0 {
+		t.Fatalf("average utilization not reset: got %v want %v", got, 0)
+	}
+}
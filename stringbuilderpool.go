@@ -0,0 +1,43 @@
+package bpool
+
+import (
+	"strings"
+)
+
+// StringBuilderPool implements a pool of strings.Builders, pre-sized like the
+// buffers of a SizedBufferPool, for code that produces strings and would
+// otherwise copy a bytes.Buffer with String.
+//
+// A string returned by strings.Builder.String shares the builder's storage,
+// so the storage can't be recycled: Put resets the builder, releasing it, and
+// Get grows the builder again. Only the builder itself and the up-front
+// sizing, which saves the reallocations of a growing builder, are reused.
+type StringBuilderPool struct {
+	builders *Pool[*strings.Builder]
+	alloc    int
+}
+
+// NewStringBuilderPool creates a new StringBuilderPool retaining up to size
+// builders, each grown to a capacity of alloc when handed out.
+func NewStringBuilderPool(size int, alloc int) (sp *StringBuilderPool) {
+	return &StringBuilderPool{
+		builders: NewResettablePool(size, func() *strings.Builder {
+			return new(strings.Builder)
+		}),
+		alloc: max(alloc, 0),
+	}
+}
+
+// Get gets an empty strings.Builder with a capacity of at least the pool's
+// alloc from the StringBuilderPool.
+func (sp *StringBuilderPool) Get() *strings.Builder {
+	sb := sp.builders.Get()
+	sb.Grow(sp.alloc)
+	return sb
+}
+
+// Put resets the given strings.Builder and returns it to the
+// StringBuilderPool. Strings obtained from it remain valid.
+func (sp *StringBuilderPool) Put(sb *strings.Builder) {
+	sp.builders.Put(sb)
+}
@@ -0,0 +1,31 @@
+package bpool
+
+import (
+	"testing"
+)
+
+func TestStringBuilderPool(t *testing.T) {
+	sp := NewStringBuilderPool(1, 64)
+
+	sb := sp.Get()
+	if sb.Cap() < 64 {
+		t.Fatalf("builder not pre-sized: got cap %v want at least %v", sb.Cap(), 64)
+	}
+	sb.WriteString("first")
+	first := sb.String()
+	sp.Put(sb)
+
+	// The recycled builder starts over, and the first string is intact.
+	sb2 := sp.Get()
+	if sb2 != sb {
+		t.Fatalf("builder not recycled")
+	}
+	if sb2.Len() != 0 || sb2.Cap() < 64 {
+		t.Fatalf("recycled builder not reset: got len %v cap %v", sb2.Len(), sb2.Cap())
+	}
+	sb2.WriteString("second")
+	if second := sb2.String(); first != "first" || second != "second" {
+		t.Fatalf("state bled across Get cycles: got %q and %q", first, second)
+	}
+	sp.Put(sb2)
+}
@@ -0,0 +1,51 @@
+package bpool
+
+import (
+	"bytes"
+	"sync"
+)
+
+// SyncBufferPool implements a pool of bytes.Buffers backed by a sync.Pool.
+// Buffers are pre-allocated to the requested size, like SizedBufferPool.
+//
+// Unlike the channel-based pools, a SyncBufferPool gives no retention
+// guarantee: the runtime may release idle buffers during any garbage
+// collection. This suits workloads that would rather let the GC decide how
+// much memory to keep, at the cost of more misses after each GC cycle. Since
+// the number of idle buffers isn't observable, there is no Len method.
+type SyncBufferPool struct {
+	p sync.Pool
+	a int
+}
+
+// NewSyncBufferPool creates a new SyncBufferPool whose new buffers have a
+// capacity of alloc.
+func NewSyncBufferPool(alloc int) (bp *SyncBufferPool) {
+	bp = &SyncBufferPool{
+		a: alloc,
+	}
+	bp.p.New = func() any {
+		return bytes.NewBuffer(make([]byte, 0, bp.a))
+	}
+	return
+}
+
+// Get gets a Buffer from the SyncBufferPool, or creates a new one if none are
+// available in the pool.
+func (bp *SyncBufferPool) Get() *bytes.Buffer {
+	return bp.p.Get().(*bytes.Buffer)
+}
+
+// Put returns the given Buffer to the SyncBufferPool.
+func (bp *SyncBufferPool) Put(b *bytes.Buffer) {
+	if b == nil {
+		return
+	}
+	b.Reset()
+	bp.p.Put(b)
+}
+
+// Cap returns the capacity of new buffers allocated by this pool.
+func (bp *SyncBufferPool) Cap() (n int) {
+	return bp.a
+}
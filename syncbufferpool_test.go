@@ -0,0 +1,43 @@
+package bpool
+
+import (
+	"testing"
+)
+
+func TestSyncBufferPool(t *testing.T) {
+	var capacity int = 1024
+
+	bufPool := NewSyncBufferPool(capacity)
+
+	b := bufPool.Get()
+	if b.Len() != 0 || b.Cap() != capacity {
+		t.Fatalf("new buffer invalid: got len %v cap %v want len %v cap %v",
+			b.Len(), b.Cap(), 0, capacity)
+	}
+
+	b.WriteString("hello")
+	bufPool.Put(b)
+	if b = bufPool.Get(); b.Len() != 0 {
+		t.Fatalf("reused buffer not empty: got %v want %v", b.Len(), 0)
+	}
+}
+
+func benchmarkPool(b *testing.B, pool BufferPooler) {
+	b.ReportAllocs()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			buf := pool.Get()
+			buf.Write(payload)
+			pool.Put(buf)
+		}
+	})
+}
+
+func BenchmarkSyncBufferPool(b *testing.B) {
+	b.Run("SyncBufferPool", func(b *testing.B) {
+		benchmarkPool(b, NewSyncBufferPool(len(payload)))
+	})
+	b.Run("SizedBufferPool", func(b *testing.B) {
+		benchmarkPool(b, NewSizedBufferPool(64, len(payload)))
+	})
+}
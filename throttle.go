@@ -0,0 +1,113 @@
+package bpool
+
+import (
+	"bytes"
+	"time"
+)
+
+// WithAllocThrottle limits the number of goroutines allocating a new buffer
+// in Get or any of its variants at once to maxConcurrent, so that a burst of
+// misses doesn't spike memory and garbage collection all at once. A Get
+// waiting for its turn takes a buffer returned in the meantime instead, if
+// any. Put never waits: it doesn't replace an oversized or aliased buffer if
+// no allocation slot is free. Prefill, the buffers kept with WithMinIdle and
+// growing reused buffers aren't limited. It has no effect together with
+// WithMaxLive, which already bounds allocations, and a maxConcurrent of 0
+// disables the limit.
+func WithAllocThrottle(maxConcurrent int) Option {
+	return func(o *options) {
+		o.allocThrottle = maxConcurrent
+	}
+}
+
+// allocLimited allocates a new buffer with alloc for a Get that found the
+// pool empty, and records the miss. It first waits until the rate limit set
+// with WithAllocRateLimit allows the allocation and fewer than the limit set
+// with WithAllocThrottle of goroutines are allocating, and reuses a buffer
+// returned while it waits instead, passing it to fit if not nil.
+func (bp *SizedBufferPool) allocLimited(alloc func() *bytes.Buffer, fit func(b *bytes.Buffer)) *bytes.Buffer {
+	for bp.rate != nil {
+		d := bp.rate.take(bp.now())
+		if d == 0 {
+			break
+		}
+		t := time.NewTimer(d)
+		b, ok := bp.waitIdle(t.C, nil)
+		t.Stop()
+		if ok {
+			return fitted(b, fit)
+		}
+	}
+	if bp.allocSem == nil {
+		bp.miss()
+		return bp.lend(alloc())
+	}
+	if b, ok := bp.waitIdle(nil, bp.allocSem); ok {
+		return fitted(b, fit)
+	}
+	bp.miss()
+	b := alloc()
+	<-bp.allocSem
+	return bp.lend(b)
+}
+
+// fitted passes b to fit, if not nil, and returns it.
+func fitted(b *bytes.Buffer, fit func(b *bytes.Buffer)) *bytes.Buffer {
+	if fit != nil {
+		fit(b)
+	}
+	return b
+}
+
+// allocNow reports whether a new buffer may be allocated right away under the
+// limits set with WithAllocRateLimit and WithAllocThrottle, for callers that
+// don't wait for them. If so, the caller must call allocDone once it has
+// allocated the buffer.
+func (bp *SizedBufferPool) allocNow() bool {
+	if bp.allocSem != nil {
+		select {
+		case bp.allocSem <- struct{}{}:
+		default:
+			return false
+		}
+	}
+	if bp.rate != nil && bp.rate.take(bp.now()) != 0 {
+		bp.allocDone()
+		return false
+	}
+	return true
+}
+
+// allocDone ends an allocation allowed by allocNow.
+func (bp *SizedBufferPool) allocDone() {
+	if bp.allocSem != nil {
+		<-bp.allocSem
+	}
+}
+
+// waitIdle waits for a buffer to be returned to the pool and reuses it. It
+// gives up and returns false once wake fires, or once it sent a token to sem,
+// which the caller then holds; either may be nil.
+func (bp *SizedBufferPool) waitIdle(wake <-chan time.Time, sem chan struct{}) (*bytes.Buffer, bool) {
+	for {
+		cur := bp.cur.Load()
+		var avail chan struct{}
+		if bp.lifo != nil {
+			avail = bp.lifo.avail
+		}
+		select {
+		case sem <- struct{}{}:
+			return nil, false
+		case <-wake:
+			return nil, false
+		case b := <-cur.c:
+			return bp.reuse(b), true
+		case <-avail:
+			if b, ok := bp.take(); ok {
+				return bp.reuse(b), true
+			}
+		case <-cur.replaced:
+			// The pool was resized; retry with the new channel.
+		}
+	}
+}
@@ -0,0 +1,97 @@
+package bpool
+
+import (
+	"bytes"
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestSizedBufferPoolAllocThrottle(t *testing.T) {
+	const limit = 2
+	var cur, peak atomic.Int64
+	bufPool := NewSizedBufferPoolWithOptions(WithSize(1), WithAlloc(64),
+		WithAllocThrottle(limit),
+		WithAllocFunc(func(capacity int) *bytes.Buffer {
+			n := cur.Add(1)
+			for p := peak.Load(); n > p && !peak.CompareAndSwap(p, n); p = peak.Load() {
+			}
+			time.Sleep(time.Millisecond)
+			cur.Add(-1)
+			return bytes.NewBuffer(make([]byte, 0, capacity))
+		}))
+
+	var wg sync.WaitGroup
+	for i := 0; i < 16; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			bufPool.Get()
+		}()
+	}
+	wg.Wait()
+
+	if p := peak.Load(); p > limit || p == 0 {
+		t.Fatalf("concurrent allocations invalid: got peak %v want at most %v", p, limit)
+	}
+	if misses := bufPool.Stats().Misses; misses != 16 {
+		t.Fatalf("misses invalid: got %v want %v", misses, 16)
+	}
+}
+
+// getVariants holds the ways of getting a buffer that may allocate one.
+var getVariants = map[string]func(bp *SizedBufferPool) *bytes.Buffer{
+	"Get":         (*SizedBufferPool).Get,
+	"GetN":        func(bp *SizedBufferPool) *bytes.Buffer { return bp.GetN(1)[0] },
+	"GetGrow":     func(bp *SizedBufferPool) *bytes.Buffer { return bp.GetGrow(128) },
+	"GetWithHint": func(bp *SizedBufferPool) *bytes.Buffer { return bp.GetWithHint(128) },
+	"GetExact":    func(bp *SizedBufferPool) *bytes.Buffer { return bp.GetExact(16) },
+	"GetWait":     func(bp *SizedBufferPool) *bytes.Buffer { return bp.GetWait(0) },
+	"GetLazy":     func(bp *SizedBufferPool) *bytes.Buffer { return bp.GetLazy().Buffer },
+	"GetCtx": func(bp *SizedBufferPool) *bytes.Buffer {
+		return bp.GetCtx(context.WithValue(context.Background(), traceKey{}, "req"))
+	},
+}
+
+func TestSizedBufferPoolAllocThrottleVariants(t *testing.T) {
+	for name, get := range getVariants {
+		bufPool := NewSizedBufferPoolWithOptions(WithSize(1), WithAlloc(64),
+			WithAllocThrottle(1), WithTracer(&fakeTracer{}))
+		// Hold the only allocation slot.
+		bufPool.allocSem <- struct{}{}
+
+		got := make(chan *bytes.Buffer)
+		go func() {
+			got <- get(bufPool)
+		}()
+		select {
+		case <-got:
+			t.Fatalf("%v: allocated while throttled", name)
+		case <-time.After(20 * time.Millisecond):
+		}
+		want := bytes.NewBuffer(make([]byte, 0, 64))
+		bufPool.Put(want)
+		if b := <-got; b != want {
+			t.Fatalf("%v: buffer returned while throttled not reused", name)
+		}
+	}
+
+	// Put doesn't wait to replace an oversized buffer, and Prefill isn't
+	// limited.
+	bufPool := NewSizedBufferPoolWithOptions(WithSize(2), WithAlloc(64),
+		WithMaxCap(64), WithAllocThrottle(1))
+	bufPool.allocSem <- struct{}{}
+	bufPool.Put(bytes.NewBuffer(make([]byte, 0, 1024)))
+	if n := bufPool.Len(); n != 0 {
+		t.Fatalf("len after throttled replacement invalid: got %v want %v", n, 0)
+	}
+	if n := bufPool.Stats().PutReplaceAllocs; n != 0 {
+		t.Fatalf("replacements while throttled invalid: got %v want %v", n, 0)
+	}
+	bufPool.Prefill()
+	if n := bufPool.Len(); n != 2 {
+		t.Fatalf("len after prefill invalid: got %v want %v", n, 2)
+	}
+}
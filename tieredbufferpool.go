@@ -0,0 +1,59 @@
+package bpool
+
+import (
+	"bytes"
+	"sort"
+)
+
+// TieredBufferPool implements a pool of bytes.Buffers split into size
+// classes, each backed by its own SizedBufferPool. It suits workloads whose
+// buffer sizes vary too widely for a single allocation size.
+type TieredBufferPool struct {
+	classes []int
+	pools   []*SizedBufferPool
+}
+
+// NewTieredBufferPool creates a new TieredBufferPool with one class per entry
+// of classes, giving the capacity of that class's buffers. Each class retains
+// up to size buffers.
+func NewTieredBufferPool(size int, classes []int) (bp *TieredBufferPool) {
+	bp = &TieredBufferPool{
+		classes: append([]int(nil), classes...),
+	}
+	sort.Ints(bp.classes)
+	bp.pools = make([]*SizedBufferPool, len(bp.classes))
+	for i, class := range bp.classes {
+		bp.pools[i] = NewSizedBufferPool(size, class)
+	}
+	return
+}
+
+// Get gets a Buffer from the smallest class.
+func (bp *TieredBufferPool) Get() *bytes.Buffer {
+	return bp.GetSize(0)
+}
+
+// GetSize gets a Buffer from the smallest class whose capacity is at least
+// n. Requests larger than the biggest class get a new buffer of capacity n
+// which is never retained by the pool.
+func (bp *TieredBufferPool) GetSize(n int) *bytes.Buffer {
+	i := sort.SearchInts(bp.classes, n)
+	if i == len(bp.classes) {
+		return bytes.NewBuffer(make([]byte, 0, n))
+	}
+	return bp.pools[i].Get()
+}
+
+// Put returns the given Buffer to the largest class it can still serve,
+// i.e. the largest class no bigger than its capacity. Buffers smaller than
+// the smallest class or bigger than the biggest class are discarded.
+func (bp *TieredBufferPool) Put(b *bytes.Buffer) {
+	if b == nil || len(bp.classes) == 0 || b.Cap() > bp.classes[len(bp.classes)-1] {
+		return
+	}
+	i := sort.SearchInts(bp.classes, b.Cap()+1) - 1
+	if i < 0 {
+		return
+	}
+	bp.pools[i].Put(b)
+}
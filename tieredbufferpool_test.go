@@ -0,0 +1,45 @@
+package bpool
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestTieredBufferPool(t *testing.T) {
+	classes := []int{16 << 10, 1 << 10, 256 << 10}
+
+	bufPool := NewTieredBufferPool(4, classes)
+
+	// Check that requests are served by the smallest class that fits.
+	for _, tc := range []struct{ n, cap int }{
+		{0, 1 << 10},
+		{1 << 10, 1 << 10},
+		{1<<10 + 1, 16 << 10},
+		{16 << 10, 16 << 10},
+		{16<<10 + 1, 256 << 10},
+		{256 << 10, 256 << 10},
+	} {
+		if b := bufPool.GetSize(tc.n); b.Cap() != tc.cap {
+			t.Fatalf("GetSize(%v) capacity invalid: got %v want %v", tc.n, b.Cap(),
+				tc.cap)
+		}
+	}
+
+	// Check that buffers are routed to the class matching their capacity.
+	bufPool.Put(bytes.NewBuffer(make([]byte, 0, 20<<10)))
+	if bufPool.pools[1].Len() != 1 {
+		t.Fatalf("buffer not routed to its class")
+	}
+	if b := bufPool.GetSize(16 << 10); b.Cap() != 20<<10 {
+		t.Fatalf("routed buffer not reused: got capacity %v", b.Cap())
+	}
+
+	// Oversized and undersized buffers are never retained.
+	bufPool.Put(bufPool.GetSize(256<<10 + 1))
+	bufPool.Put(bytes.NewBuffer(make([]byte, 0, 512)))
+	for i, pool := range bufPool.pools {
+		if pool.Len() != 0 {
+			t.Fatalf("class %v retained an out-of-range buffer", bufPool.classes[i])
+		}
+	}
+}
@@ -0,0 +1,40 @@
+package bpool
+
+import (
+	"bytes"
+	"context"
+)
+
+// MissEvent is the name of the event GetCtx records when it has to allocate
+// a new buffer.
+const MissEvent = "bpool.miss"
+
+// Tracer records events on the trace span carried by a context, e.g. by
+// adapting an OpenTelemetry span. It must be safe for concurrent use.
+type Tracer interface {
+	Event(ctx context.Context, name string)
+}
+
+// WithTracer sets the Tracer GetCtx records pool misses with.
+func WithTracer(t Tracer) Option {
+	return func(o *options) {
+		o.tracer = t
+	}
+}
+
+// GetCtx gets a Buffer from the SizedBufferPool like Get, and records a
+// MissEvent with the Tracer set by WithTracer on the span in ctx if a new
+// buffer had to be allocated, so that allocation spikes can be correlated
+// with the requests causing them. It allocates like Get, waiting only for the
+// limits set with WithAllocRateLimit and WithAllocThrottle, and unlike
+// GetContext doesn't treat the pool size as a limit. With WithMaxLive, or
+// without a Tracer, it is the same as Get and records nothing.
+func (bp *SizedBufferPool) GetCtx(ctx context.Context) *bytes.Buffer {
+	if bp.opt.tracer == nil {
+		return bp.Get()
+	}
+	return bp.getWith(func() *bytes.Buffer {
+		bp.opt.tracer.Event(ctx, MissEvent)
+		return bp.get()
+	})
+}
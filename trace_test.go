@@ -0,0 +1,33 @@
+package bpool
+
+import (
+	"context"
+	"slices"
+	"testing"
+)
+
+type traceKey struct{}
+
+// fakeTracer records the events along with the request id in their context.
+type fakeTracer struct {
+	events []string
+}
+
+func (t *fakeTracer) Event(ctx context.Context, name string) {
+	t.events = append(t.events, ctx.Value(traceKey{}).(string)+" "+name)
+}
+
+func TestSizedBufferPoolGetCtx(t *testing.T) {
+	tracer := &fakeTracer{}
+	bufPool := NewSizedBufferPoolWithOptions(WithSize(1), WithAlloc(64),
+		WithTracer(tracer))
+
+	// The first Get misses, the second hits.
+	b := bufPool.GetCtx(context.WithValue(context.Background(), traceKey{}, "req1"))
+	bufPool.Put(b)
+	bufPool.GetCtx(context.WithValue(context.Background(), traceKey{}, "req2"))
+
+	if want := []string{"req1 " + MissEvent}; !slices.Equal(tracer.events, want) {
+		t.Fatalf("traced events invalid: got %v want %v", tracer.events, want)
+	}
+}
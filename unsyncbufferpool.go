@@ -0,0 +1,53 @@
+package bpool
+
+import (
+	"bytes"
+)
+
+// UnsyncBufferPool implements a pool of bytes.Buffers as a plain stack,
+// without any synchronization. It avoids the cost of a channel in code that
+// gets and puts buffers from a single goroutine, such as a parser or a single
+// encoding worker.
+//
+// An UnsyncBufferPool is NOT safe for concurrent use.
+type UnsyncBufferPool struct {
+	bufs []*bytes.Buffer
+	a    int
+}
+
+// NewUnsyncBufferPool creates a new UnsyncBufferPool retaining up to size
+// buffers, with new buffers allocated with a capacity of alloc.
+func NewUnsyncBufferPool(size int, alloc int) (bp *UnsyncBufferPool) {
+	return &UnsyncBufferPool{
+		bufs: make([]*bytes.Buffer, 0, max(size, 0)),
+		a:    alloc,
+	}
+}
+
+// Get gets the most recently returned Buffer from the UnsyncBufferPool, or
+// creates a new one if none are available in the pool.
+func (bp *UnsyncBufferPool) Get() *bytes.Buffer {
+	n := len(bp.bufs)
+	if n == 0 {
+		return bytes.NewBuffer(make([]byte, 0, bp.a))
+	}
+	b := bp.bufs[n-1]
+	bp.bufs[n-1] = nil
+	bp.bufs = bp.bufs[:n-1]
+	return b
+}
+
+// Put returns the given Buffer to the UnsyncBufferPool, discarding it if the
+// pool is full.
+func (bp *UnsyncBufferPool) Put(b *bytes.Buffer) {
+	if b == nil || len(bp.bufs) == cap(bp.bufs) {
+		return
+	}
+	b.Reset()
+	bp.bufs = append(bp.bufs, b)
+}
+
+// Len returns the number of buffers currently retained by the pool.
+func (bp *UnsyncBufferPool) Len() int {
+	return len(bp.bufs)
+}
@@ -0,0 +1,60 @@
+package bpool
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestUnsyncBufferPool(t *testing.T) {
+	bufPool := NewUnsyncBufferPool(2, 64)
+
+	a, b, c := bufPool.Get(), bufPool.Get(), bufPool.Get()
+	if a.Cap() != 64 {
+		t.Fatalf("new buffer capacity invalid: got %v want %v", a.Cap(), 64)
+	}
+	a.WriteString("a")
+	bufPool.Put(a)
+	bufPool.Put(b)
+	bufPool.Put(c)
+	if bufPool.Len() != 2 {
+		t.Fatalf("pool size exceeded: got %v want %v", bufPool.Len(), 2)
+	}
+
+	// Buffers are reused most recently returned first.
+	if got := bufPool.Get(); got != b {
+		t.Fatalf("last returned buffer not reused first")
+	}
+	if got := bufPool.Get(); got != a || got.Len() != 0 {
+		t.Fatalf("first returned buffer not reused last, or not reset")
+	}
+	bufPool.Put(nil)
+	if bufPool.Len() != 0 {
+		t.Fatalf("nil buffer retained")
+	}
+}
+
+// benchmarkSerial gets, fills and puts buffers from a single goroutine.
+func benchmarkSerial(b *testing.B, pool BufferPooler) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		buf := pool.Get()
+		buf.Write(payload)
+		pool.Put(buf)
+	}
+}
+
+func BenchmarkUnsyncBufferPool(b *testing.B) {
+	b.Run("UnsyncBufferPool", func(b *testing.B) {
+		benchmarkSerial(b, NewUnsyncBufferPool(64, len(payload)))
+	})
+	b.Run("SizedBufferPool", func(b *testing.B) {
+		benchmarkSerial(b, NewSizedBufferPool(64, len(payload)))
+	})
+	b.Run("NoPool", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			buf := bytes.NewBuffer(make([]byte, 0, len(payload)))
+			buf.Write(payload)
+		}
+	})
+}
@@ -0,0 +1,79 @@
+package bpool
+
+import (
+	"bytes"
+	"sync"
+)
+
+// WeightedBufferPool implements a pool of bytes.Buffers bounded by the total
+// capacity of the retained buffers rather than by their number, which bounds
+// memory use even when buffer sizes vary widely.
+type WeightedBufferPool struct {
+	mu       sync.Mutex
+	bufs     []*bytes.Buffer
+	retained int
+
+	max int
+	a   int
+}
+
+// NewWeightedBufferPool creates a new WeightedBufferPool retaining buffers
+// with a total capacity of up to maxBytes, with new buffers allocated with a
+// capacity of alloc.
+func NewWeightedBufferPool(maxBytes int, alloc int) (bp *WeightedBufferPool) {
+	return &WeightedBufferPool{
+		max: maxBytes,
+		a:   alloc,
+	}
+}
+
+// Get gets the most recently returned Buffer from the WeightedBufferPool, or
+// creates a new one if none are available in the pool.
+func (bp *WeightedBufferPool) Get() *bytes.Buffer {
+	bp.mu.Lock()
+	if n := len(bp.bufs); n > 0 {
+		b := bp.bufs[n-1]
+		bp.bufs[n-1] = nil
+		bp.bufs = bp.bufs[:n-1]
+		bp.retained -= b.Cap()
+		bp.mu.Unlock()
+		return b
+	}
+	bp.mu.Unlock()
+	return bytes.NewBuffer(make([]byte, 0, bp.a))
+}
+
+// Put returns the given Buffer to the WeightedBufferPool. If retaining it
+// would exceed the pool's byte limit, the least recently returned buffers are
+// evicted to make room. Buffers bigger than the whole limit are discarded.
+func (bp *WeightedBufferPool) Put(b *bytes.Buffer) {
+	if b == nil || b.Cap() > bp.max {
+		return
+	}
+	b.Reset()
+	bp.mu.Lock()
+	defer bp.mu.Unlock()
+	evict := 0
+	for bp.retained+b.Cap() > bp.max {
+		bp.retained -= bp.bufs[evict].Cap()
+		bp.bufs[evict] = nil
+		evict++
+	}
+	bp.bufs = append(bp.bufs[evict:], b)
+	bp.retained += b.Cap()
+}
+
+// RetainedBytes returns the total capacity of the buffers currently retained
+// by the pool.
+func (bp *WeightedBufferPool) RetainedBytes() int {
+	bp.mu.Lock()
+	defer bp.mu.Unlock()
+	return bp.retained
+}
+
+// Len returns the number of buffers currently retained by the pool.
+func (bp *WeightedBufferPool) Len() int {
+	bp.mu.Lock()
+	defer bp.mu.Unlock()
+	return len(bp.bufs)
+}
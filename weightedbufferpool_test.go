@@ -0,0 +1,40 @@
+package bpool
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestWeightedBufferPool(t *testing.T) {
+	const maxBytes = 4096
+	bufPool := NewWeightedBufferPool(maxBytes, 64)
+
+	if b := bufPool.Get(); b.Cap() != 64 {
+		t.Fatalf("new buffer capacity invalid: got %v want %v", b.Cap(), 64)
+	}
+
+	// Mix small and large buffers; the ceiling is never exceeded.
+	for _, capacity := range []int{1024, 64, 2048, 64, 3000, 128, 4096, 64, 5000} {
+		bufPool.Put(bytes.NewBuffer(make([]byte, 0, capacity)))
+		if got := bufPool.RetainedBytes(); got > maxBytes {
+			t.Fatalf("byte ceiling exceeded: got %v want at most %v", got, maxBytes)
+		}
+	}
+
+	// The 4096 byte buffer evicted everything before it, and was in turn
+	// evicted by the following 64 byte one. The 5000 byte buffer was
+	// discarded.
+	if bufPool.Len() != 1 || bufPool.RetainedBytes() != 64 {
+		t.Fatalf("retained invalid: got %v buffers, %v bytes want 1, 64", bufPool.Len(),
+			bufPool.RetainedBytes())
+	}
+
+	bufPool.Put(bytes.NewBuffer(make([]byte, 0, 1024)))
+	bufPool.Put(bytes.NewBuffer(make([]byte, 0, 2048)))
+	if got := bufPool.Get(); got.Cap() != 2048 {
+		t.Fatalf("most recent buffer not reused: got capacity %v want %v", got.Cap(), 2048)
+	}
+	if got := bufPool.RetainedBytes(); got != 64+1024 {
+		t.Fatalf("retained bytes invalid: got %v want %v", got, 64+1024)
+	}
+}